@@ -0,0 +1,266 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// cfbSignature は OLE 複合ファイル (.xls, .doc, .ppt などの旧形式) の先頭8バイト。
+var cfbSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// 複合ファイルのFATの特殊な値。
+const (
+	cfbEndOfChain = 0xFFFFFFFE
+	cfbFreeSect   = 0xFFFFFFFF
+)
+
+// ディレクトリエントリの種類。
+const (
+	cfbTypeStorage = 1
+	cfbTypeStream  = 2
+	cfbTypeRoot    = 5
+)
+
+var errInvalidCFB = errors.New("OLE 複合ファイルの形式が正しくありません。")
+
+// cfbFile は OLE 複合ファイル (Compound File Binary) のディレクトリを読み込んだもの。
+// Officeを起動せずにファイルの種類を判定するため、必要な部分のみを読み込む。
+type cfbFile struct {
+	r          io.ReaderAt
+	size       int64
+	sectorSize int64
+	fat        []uint32
+	entries    []cfbEntry
+
+	// miniCutoff 未満のサイズのストリームは、64バイト単位のミニストリームに格納される。
+	miniCutoff   uint64
+	miniFatStart uint32
+	miniFat      []uint32
+}
+
+// cfbEntry は複合ファイルのストレージまたはストリーム。
+type cfbEntry struct {
+	name  string
+	typ   byte
+	start uint32
+	size  uint64
+}
+
+// openCFB は r から複合ファイルのヘッダ、FATとディレクトリを読み込む。
+func openCFB(r io.ReaderAt, size int64) (*cfbFile, error) {
+	header := make([]byte, 512)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, err
+	}
+	if string(header[:8]) != string(cfbSignature) {
+		return nil, errInvalidCFB
+	}
+
+	shift := binary.LittleEndian.Uint16(header[0x1E:])
+	if shift != 9 && shift != 12 {
+		return nil, errInvalidCFB
+	}
+	f := &cfbFile{
+		r:            r,
+		size:         size,
+		sectorSize:   1 << shift,
+		miniCutoff:   uint64(binary.LittleEndian.Uint32(header[0x38:])),
+		miniFatStart: binary.LittleEndian.Uint32(header[0x3C:]),
+	}
+
+	// FATのセクタ番号は、ヘッダの109個と、DIFATセクタのチェーンに記録されている。
+	numFat := binary.LittleEndian.Uint32(header[0x2C:])
+	if int64(numFat) > size/f.sectorSize+1 {
+		return nil, errInvalidCFB
+	}
+	var fatSectors []uint32
+	for i := 0; i < 109 && uint32(len(fatSectors)) < numFat; i++ {
+		fatSectors = append(fatSectors, binary.LittleEndian.Uint32(header[0x4C+i*4:]))
+	}
+	difat := binary.LittleEndian.Uint32(header[0x44:])
+	for n := 0; uint32(len(fatSectors)) < numFat && difat != cfbEndOfChain && difat != cfbFreeSect; n++ {
+		if int64(n) > size/f.sectorSize {
+			return nil, errInvalidCFB
+		}
+		buf, err := f.readSector(difat)
+		if err != nil {
+			return nil, err
+		}
+		last := len(buf)/4 - 1
+		for i := 0; i < last && uint32(len(fatSectors)) < numFat; i++ {
+			fatSectors = append(fatSectors, binary.LittleEndian.Uint32(buf[i*4:]))
+		}
+		difat = binary.LittleEndian.Uint32(buf[last*4:])
+	}
+	for _, sec := range fatSectors {
+		buf, err := f.readSector(sec)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < len(buf); i += 4 {
+			f.fat = append(f.fat, binary.LittleEndian.Uint32(buf[i:]))
+		}
+	}
+
+	dirSectors, err := f.chain(binary.LittleEndian.Uint32(header[0x30:]))
+	if err != nil {
+		return nil, err
+	}
+	for _, sec := range dirSectors {
+		buf, err := f.readSector(sec)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i+128 <= len(buf); i += 128 {
+			f.entries = append(f.entries, parseCFBEntry(buf[i:i+128], shift == 9))
+		}
+	}
+	if len(f.entries) == 0 || f.entries[0].typ != cfbTypeRoot {
+		return nil, errInvalidCFB
+	}
+	return f, nil
+}
+
+// parseCFBEntry は128バイトのディレクトリエントリを解析する。
+func parseCFBEntry(buf []byte, v3 bool) cfbEntry {
+	e := cfbEntry{
+		typ:   buf[0x42],
+		start: binary.LittleEndian.Uint32(buf[0x74:]),
+		size:  binary.LittleEndian.Uint64(buf[0x78:]),
+	}
+	// バージョン3では、サイズの上位32ビットは使用しない。
+	if v3 {
+		e.size &= 0xFFFFFFFF
+	}
+	n := int(binary.LittleEndian.Uint16(buf[0x40:]))
+	if n > 64 {
+		n = 64
+	}
+	name := make([]uint16, 0, n/2)
+	for i := 0; i+1 < n; i += 2 {
+		c := binary.LittleEndian.Uint16(buf[i:])
+		if c == 0 {
+			break
+		}
+		name = append(name, c)
+	}
+	e.name = string(utf16.Decode(name))
+	return e
+}
+
+// readSector はセクタ番号 sec のセクタを読み込む。
+func (f *cfbFile) readSector(sec uint32) ([]byte, error) {
+	off := (int64(sec) + 1) * f.sectorSize
+	if off+f.sectorSize > f.size {
+		return nil, errInvalidCFB
+	}
+	buf := make([]byte, f.sectorSize)
+	if _, err := f.r.ReadAt(buf, off); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// chain は start から始まるセクタのチェーンを、FATを辿って返す。
+func (f *cfbFile) chain(start uint32) ([]uint32, error) {
+	var secs []uint32
+	for sec := start; sec != cfbEndOfChain; sec = f.fat[sec] {
+		if int(sec) >= len(f.fat) || len(secs) > len(f.fat) {
+			return nil, errInvalidCFB
+		}
+		secs = append(secs, sec)
+	}
+	return secs, nil
+}
+
+// stream は name のストリームを返す。ストリーム名は大文字と小文字を区別しない。
+func (f *cfbFile) stream(name string) (cfbEntry, bool) {
+	for _, e := range f.entries {
+		if e.typ == cfbTypeStream && strings.EqualFold(e.name, name) {
+			return e, true
+		}
+	}
+	return cfbEntry{}, false
+}
+
+// cfbMiniSectorSize はミニストリームのセクタのサイズ。
+const cfbMiniSectorSize = 64
+
+// readStream はストリーム e の先頭から、最大 limit バイトを読み込む。
+func (f *cfbFile) readStream(e cfbEntry, limit int64) ([]byte, error) {
+	size := int64(e.size)
+	if size > limit {
+		size = limit
+	}
+	if size <= 0 {
+		return nil, nil
+	}
+	if e.size < f.miniCutoff {
+		return f.readMiniStream(e.start, size)
+	}
+
+	secs, err := f.chain(e.start)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 0, size)
+	for _, sec := range secs {
+		if int64(len(buf)) >= size {
+			break
+		}
+		data, err := f.readSector(sec)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, data...)
+	}
+	if int64(len(buf)) < size {
+		return nil, errInvalidCFB
+	}
+	return buf[:size], nil
+}
+
+// readMiniStream はミニストリームの start から始まるチェーンを、size バイト読み込む。
+// ミニストリーム自体は、ルートエントリのストリームとして通常のセクタに格納されている。
+func (f *cfbFile) readMiniStream(start uint32, size int64) ([]byte, error) {
+	if f.miniFat == nil {
+		secs, err := f.chain(f.miniFatStart)
+		if err != nil {
+			return nil, err
+		}
+		for _, sec := range secs {
+			data, err := f.readSector(sec)
+			if err != nil {
+				return nil, err
+			}
+			for i := 0; i < len(data); i += 4 {
+				f.miniFat = append(f.miniFat, binary.LittleEndian.Uint32(data[i:]))
+			}
+		}
+	}
+	container, err := f.chain(f.entries[0].start)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 0, size)
+	for sec, n := start, 0; int64(len(buf)) < size; sec, n = f.miniFat[sec], n+1 {
+		if sec == cfbEndOfChain || int(sec) >= len(f.miniFat) || n > len(f.miniFat) {
+			return nil, errInvalidCFB
+		}
+		off := int64(sec) * cfbMiniSectorSize
+		i := off / f.sectorSize
+		if i >= int64(len(container)) {
+			return nil, errInvalidCFB
+		}
+		data := make([]byte, cfbMiniSectorSize)
+		if _, err := f.r.ReadAt(data, (int64(container[i])+1)*f.sectorSize+off%f.sectorSize); err != nil {
+			return nil, err
+		}
+		buf = append(buf, data...)
+	}
+	return buf[:size], nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slog"
+)
+
+// 出力するPDFファイル名が重複した場合の扱い (-collision)。
+const (
+	// collisionExt は重複したファイル全てに、変換元ファイルの拡張子を付ける (foo_docx.pdf, foo_xlsx.pdf)。
+	collisionExt = "ext"
+	// collisionNumber は2つ目以降のファイルに連番を付ける (foo.pdf, foo_2.pdf)。
+	collisionNumber = "number"
+	// collisionFail は変換を行わずにエラーとする。
+	collisionFail = "fail"
+)
+
+// checkCollisionPolicy は -collision の指定が正しいかを確認する。
+func checkCollisionPolicy(policy string) error {
+	switch policy {
+	case collisionExt, collisionNumber, collisionFail:
+		return nil
+	}
+	return fmt.Errorf("-collision の指定が正しくありません: %s", policy)
+}
+
+// resolveCollisions は出力するPDFファイルが重複する計画を検出し、policy に従って出力先を変更する。
+// policy が collisionFail の場合は、重複したファイルの一覧をエラーとして返す。
+func resolveCollisions(entries []*planEntry, policy string) error {
+	if policy == "" {
+		policy = collisionExt
+	}
+	if err := checkCollisionPolicy(policy); err != nil {
+		return err
+	}
+
+	groups, order, err := groupByTarget(entries)
+	if err != nil {
+		return err
+	}
+
+	// taken は出力先のPDFファイル (小文字)。連番を付ける時に、他のファイルの出力先と重複しないようにする。
+	taken := map[string]bool{}
+	for _, key := range order {
+		taken[key] = true
+	}
+
+	var msgs []string
+	for _, key := range order {
+		group := groups[key]
+		if len(group) < 2 {
+			continue
+		}
+		sources := make([]string, len(group))
+		for i, e := range group {
+			sources[i] = e.Source
+		}
+		slog.Warn("出力するPDFファイルが重複しています。", "PDFファイル", group[0].Target, "変換元", strings.Join(sources, ", "))
+
+		switch policy {
+		case collisionFail:
+			msgs = append(msgs, group[0].Target+" ("+strings.Join(sources, ", ")+")")
+		case collisionExt:
+			// 同じ拡張子のファイルが重複している場合は、拡張子では区別できないため連番を付ける。
+			if !uniqueExts(group) {
+				if err := numberGroup(group, taken); err != nil {
+					return err
+				}
+				continue
+			}
+			for _, e := range group {
+				e.Target = addPdfSuffix(e.Target, strings.TrimPrefix(strings.ToLower(filepath.Ext(e.Source)), "."))
+			}
+		case collisionNumber:
+			if err := numberGroup(group, taken); err != nil {
+				return err
+			}
+		}
+	}
+	if len(msgs) > 0 {
+		return fmt.Errorf("出力するPDFファイルが重複しています: %s", strings.Join(msgs, "; "))
+	}
+
+	// 名前を変更した結果、別のファイルと重複していないかを確認する。
+	groups, order, err = groupByTarget(entries)
+	if err != nil {
+		return err
+	}
+	for _, key := range order {
+		if group := groups[key]; len(group) > 1 {
+			return fmt.Errorf("出力するPDFファイルの重複を解消できません: %s", group[0].Target)
+		}
+	}
+	return nil
+}
+
+// uniqueExts は group の変換元ファイルの拡張子が、全て異なる場合に true を返す。
+func uniqueExts(group []*planEntry) bool {
+	seen := map[string]bool{}
+	for _, e := range group {
+		ext := strings.ToLower(filepath.Ext(e.Source))
+		if seen[ext] {
+			return false
+		}
+		seen[ext] = true
+	}
+	return true
+}
+
+// numberGroup は group の2つ目以降のファイルの出力先に連番を付ける。
+// taken に含まれる出力先は使用せず、付けた出力先を taken に追加する。
+func numberGroup(group []*planEntry, taken map[string]bool) error {
+	n := 2
+	for _, e := range group[1:] {
+		base := e.Target
+		for {
+			e.Target = addPdfSuffix(base, strconv.Itoa(n))
+			n++
+			full, err := filepath.Abs(e.Target)
+			if err != nil {
+				return err
+			}
+			if key := strings.ToLower(full); !taken[key] {
+				taken[key] = true
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// groupByTarget は計画を出力先のPDFファイルごとにまとめる。
+// Windows のファイル名は大文字と小文字を区別しないため、小文字に揃えて比較する。
+func groupByTarget(entries []*planEntry) (groups map[string][]*planEntry, order []string, err error) {
+	groups = map[string][]*planEntry{}
+	for _, e := range entries {
+		full, err := filepath.Abs(e.Target)
+		if err != nil {
+			return nil, nil, err
+		}
+		key := strings.ToLower(full)
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], e)
+	}
+	return groups, order, nil
+}
+
+// addPdfSuffix は PDFファイルのパスの拡張子の前に、_suffix を追加する。
+func addPdfSuffix(pdfPath, suffix string) string {
+	return getPathWithoutExt(pdfPath) + "_" + suffix + filepath.Ext(pdfPath)
+}
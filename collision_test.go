@@ -0,0 +1,78 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveCollisions(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "foo.xlsx", "foo.docx", "foo.pptx", "bar.xlsx")
+
+	tests := []struct {
+		policy string
+		want   map[string]string
+	}{
+		{collisionExt, map[string]string{
+			"foo.xlsx": "foo_xlsx.pdf",
+			"foo.docx": "foo_docx.pdf",
+			"foo.pptx": "foo_pptx.pdf",
+			"bar.xlsx": "bar.pdf",
+		}},
+		{collisionNumber, map[string]string{
+			"foo.xlsx": "foo.pdf",
+			"foo.docx": "foo_2.pdf",
+			"foo.pptx": "foo_3.pdf",
+			"bar.xlsx": "bar.pdf",
+		}},
+	}
+
+	for _, tt := range tests {
+		entries, err := buildPlan([]string{dir}, runOptions{Collision: tt.policy})
+		if err != nil {
+			t.Errorf("%s: %v", tt.policy, err)
+			continue
+		}
+		for _, e := range entries {
+			want := filepath.Join(dir, tt.want[filepath.Base(e.Source)])
+			if e.Target != want {
+				t.Errorf("%s: target of %s = %s, want %s", tt.policy, filepath.Base(e.Source), e.Target, want)
+			}
+		}
+	}
+
+	if _, err := buildPlan([]string{dir}, runOptions{Collision: collisionFail}); err == nil {
+		t.Error("fail: buildPlan succeeded, want error")
+	}
+	if _, err := buildPlan([]string{dir}, runOptions{Collision: "skip"}); err == nil {
+		t.Error("unknown policy: buildPlan succeeded, want error")
+	}
+}
+
+func TestResolveCollisionsSameName(t *testing.T) {
+	// 2つの対象フォルダの同じ名前のファイルを、-o で同じフォルダに出力する。
+	dir := t.TempDir()
+	first, second, out := filepath.Join(dir, "first"), filepath.Join(dir, "second"), filepath.Join(dir, "out")
+	writeFiles(t, first, "a.xlsx", "a.docx")
+	writeFiles(t, second, "a.xlsx", "a_2.xlsx")
+
+	for _, policy := range []string{collisionExt, collisionNumber} {
+		entries, err := buildPlan([]string{first, second}, runOptions{OutDir: out, Collision: policy})
+		if err != nil {
+			t.Errorf("%s: %v", policy, err)
+			continue
+		}
+		got := map[string]bool{}
+		for _, e := range entries {
+			if got[e.Target] {
+				t.Errorf("%s: duplicated target %s", policy, e.Target)
+			}
+			got[e.Target] = true
+		}
+		for _, name := range []string{"a.pdf", "a_2.pdf", "a_3.pdf", "a_4.pdf"} {
+			if !got[filepath.Join(out, name)] {
+				t.Errorf("%s: targets = %v, want %s", policy, got, name)
+			}
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/exp/slog"
+)
+
+// AppType はPDF変換に使用するOfficeアプリケーションの種類。
+type AppType int
+
+const (
+	AppExcel AppType = iota
+	AppWord
+	AppPowerPoint
+)
+
+func (a AppType) String() string {
+	switch a {
+	case AppExcel:
+		return "Excel"
+	case AppWord:
+		return "Word"
+	case AppPowerPoint:
+		return "PowerPoint"
+	}
+	return "Unknown"
+}
+
+// Converter はOfficeアプリケーションを使用して、ファイルをPDFに変換する。
+// Open で起動したアプリケーションは、Quit を呼ぶまで Convert で繰り返し使用できる。
+type Converter interface {
+	// Open はアプリケーションを起動する。
+	Open() error
+	// Convert は src のファイルを export の指定でPDFに変換し、dst に出力する。
+	// ctx が終了した場合に変換を中断できない実装もあるため、呼び出し側で待ち時間を制限する。
+	Convert(ctx context.Context, src, dst string, export exportOptions) (ConvertResult, error)
+	// Quit はアプリケーションを終了する。
+	Quit() error
+}
+
+// ConvertResult は1ファイルの変換結果の詳細。
+type ConvertResult struct {
+	// Count はPDFに出力したExcelのシート数、またはPowerPointのスライド数。不明な場合は 0。
+	Count int
+	// Outputs はシートごとに出力した場合の、出力したPDFファイルのパス。
+	Outputs []string
+	// Sheets はPDFに出力したExcelのシート。
+	Sheets []exportedSheet
+	// SkipReason はPDFを出力せずにスキップした場合の理由。
+	SkipReason string
+}
+
+// Killer は応答しなくなったアプリケーションを強制終了できる Converter が実装する。
+// Kill は変換中に他のゴルーチンから呼び出す。Kill の後は、Open と同じスレッドで Release を呼び出し、
+// その Converter を使用しない。
+type Killer interface {
+	Kill() error
+	Release()
+}
+
+// converterFactory はアプリケーションの種類に応じた Converter を生成する。
+type converterFactory func(app AppType) Converter
+
+// FileError はファイルごとのPDF変換のエラー。
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// t のファイルを conv でPDFに変換する。出力先のフォルダが無い場合は作成する。
+// シートごとに出力する場合、シート名を含むフォルダは conv が作成する。
+func convertFile(ctx context.Context, conv Converter, t task) (ConvertResult, error) {
+	fullpath, err := filepath.Abs(t.Path)
+	if err != nil {
+		return ConvertResult{}, err
+	}
+	if t.Snapshot {
+		snapshot, cleanup, err := snapshotFile(fullpath)
+		if err != nil {
+			return ConvertResult{}, err
+		}
+		defer cleanup()
+		fullpath = snapshot
+	}
+	pdfFullPath, err := filepath.Abs(t.PdfPath)
+	if err != nil {
+		return ConvertResult{}, err
+	}
+	// フォルダ名に {sheet} を含む場合は、シートごとの出力先フォルダを変換時に作成する。
+	if dir := filepath.Dir(pdfFullPath); !strings.Contains(dir, sheetMarker) {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return ConvertResult{}, err
+		}
+	}
+
+	name := filepath.Base(t.Path)
+	res, err := conv.Convert(ctx, fullpath, pdfFullPath, t.Export)
+	if err != nil {
+		slog.Error(name+" 変換失敗", "err", err, "PDFファイル", t.PdfPath)
+		return res, err
+	}
+	if res.SkipReason != "" {
+		slog.Info(name+" スキップ", "理由", res.SkipReason)
+		return res, nil
+	}
+	if len(res.Outputs) > 0 {
+		slog.Info(name+" 変換完了", "PDFファイル数", len(res.Outputs))
+		return res, nil
+	}
+	slog.Info(name+" 変換完了", "PDFファイル", t.PdfPath)
+	return res, nil
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// fakeBackend はアプリケーションを起動せずに、Converter の呼び出しをメモリ上に記録する。
+type fakeBackend struct {
+	mu     sync.Mutex
+	events map[AppType][]string
+	// fail はファイル名ごとに Convert が返すエラー。
+	fail map[string]error
+	// skip はファイル名ごとに Convert がスキップとする理由。
+	skip map[string]string
+	// hang は Convert が ctx の終了まで応答しなくなるファイル名。
+	hang map[string]bool
+	// killErr は Kill が返すエラー。
+	killErr error
+	// openErrs は Open が先頭から順に返すエラー。無くなった後の Open は成功する。
+	openErrs []error
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{
+		events: map[AppType][]string{},
+		fail:   map[string]error{},
+		skip:   map[string]string{},
+		hang:   map[string]bool{},
+	}
+}
+
+func (b *fakeBackend) newConverter(app AppType) Converter {
+	return &fakeConverter{backend: b, app: app}
+}
+
+func (b *fakeBackend) record(app AppType, event string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events[app] = append(b.events[app], event)
+}
+
+func (b *fakeBackend) eventsOf(app AppType) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.events[app]...)
+}
+
+// fakePdf は fakeConverter が出力するPDFファイルの内容。
+const fakePdf = "%PDF-1.4 fake"
+
+// fakeConverter は fakeBackend に呼び出しを記録し、ダミーのPDFを出力する Converter。
+type fakeConverter struct {
+	backend *fakeBackend
+	app     AppType
+}
+
+func (c *fakeConverter) Open() error {
+	c.backend.record(c.app, "open")
+	c.backend.mu.Lock()
+	defer c.backend.mu.Unlock()
+	if len(c.backend.openErrs) > 0 {
+		err := c.backend.openErrs[0]
+		c.backend.openErrs = c.backend.openErrs[1:]
+		return err
+	}
+	return nil
+}
+
+func (c *fakeConverter) Convert(ctx context.Context, src, dst string, export exportOptions) (ConvertResult, error) {
+	name := filepath.Base(src)
+	c.backend.record(c.app, "convert "+name)
+	if c.backend.hang[name] {
+		<-ctx.Done()
+		return ConvertResult{}, ctx.Err()
+	}
+	if err := c.backend.fail[name]; err != nil {
+		return ConvertResult{}, err
+	}
+	if reason := c.backend.skip[name]; reason != "" {
+		return ConvertResult{SkipReason: reason}, nil
+	}
+	// シートごとに出力する場合は、Sheet1 だけのブックとして出力する。
+	if export.SplitSheets {
+		pdf := sheetPdfPath(dst, "Sheet1", map[string]bool{})
+		if err := os.MkdirAll(filepath.Dir(pdf), 0o755); err != nil {
+			return ConvertResult{}, err
+		}
+		return ConvertResult{Count: 1, Outputs: []string{pdf}}, os.WriteFile(pdf, []byte(fakePdf), 0o644)
+	}
+	return ConvertResult{Count: 1}, os.WriteFile(dst, []byte(fakePdf), 0o644)
+}
+
+func (c *fakeConverter) Quit() error {
+	c.backend.record(c.app, "quit")
+	return nil
+}
+
+func (c *fakeConverter) Kill() error {
+	c.backend.record(c.app, "kill")
+	return c.backend.killErr
+}
+
+func (c *fakeConverter) Release() {}
+
+// dir 配下に空のファイルを作成する。
+func writeFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.xlsx", "b.xls", "c.docx", "d.pptx", "memo.txt")
+
+	backend := newFakeBackend()
+	result, err := run([]string{dir}, backend.newConverter, runOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Errs) != 0 {
+		t.Fatalf("unexpected errors: %v", result.Errs)
+	}
+	if result.Total != 4 || result.Converted != 4 {
+		t.Errorf("result = %+v, want 4 converted", result)
+	}
+
+	tests := []struct {
+		app  AppType
+		want []string
+	}{
+		{AppExcel, []string{"open", "convert a.xlsx", "convert b.xls", "quit"}},
+		{AppWord, []string{"open", "convert c.docx", "quit"}},
+		{AppPowerPoint, []string{"open", "convert d.pptx", "quit"}},
+	}
+	for _, tt := range tests {
+		if got := backend.eventsOf(tt.app); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v events = %v, want %v", tt.app, got, tt.want)
+		}
+	}
+
+	for _, name := range []string{"a.pdf", "b.pdf", "c.pdf", "d.pdf"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("PDF not created: %v", err)
+		}
+	}
+}
+
+func TestRunError(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.xlsx", "b.xlsx", "c.xlsx")
+
+	backend := newFakeBackend()
+	errBroken := errors.New("broken")
+	backend.fail["b.xlsx"] = errBroken
+
+	result, err := run([]string{dir}, backend.newConverter, runOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Converted != 2 || result.Failed() != 1 {
+		t.Errorf("result = %+v, want 2 converted and 1 failed", result)
+	}
+	if len(result.Errs) != 1 || !errors.Is(result.Errs[0], errBroken) {
+		t.Fatalf("errs = %v, want [%v]", result.Errs, errBroken)
+	}
+	var fe *FileError
+	if !errors.As(result.Errs[0], &fe) || fe.Path != filepath.Join(dir, "b.xlsx") {
+		t.Errorf("error does not record the path: %v", result.Errs[0])
+	}
+
+	// 失敗したファイルがあっても、残りのファイルを変換する。
+	want := []string{"open", "convert a.xlsx", "convert b.xlsx", "convert c.xlsx", "quit"}
+	if got := backend.eventsOf(AppExcel); !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+	// 対象ファイルの無いアプリケーションは起動しない。
+	if got := backend.eventsOf(AppWord); len(got) != 0 {
+		t.Errorf("Word events = %v, want none", got)
+	}
+}
+
+func TestRunFailFast(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.xlsx", "b.xlsx", "c.xlsx")
+
+	backend := newFakeBackend()
+	backend.fail["b.xlsx"] = errors.New("broken")
+
+	result, err := run([]string{dir}, backend.newConverter, runOptions{FailFast: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Converted != 1 || result.Failed() != 2 {
+		t.Errorf("result = %+v, want 1 converted and 2 failed", result)
+	}
+
+	want := []string{"open", "convert a.xlsx", "convert b.xlsx", "quit"}
+	if got := backend.eventsOf(AppExcel); !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestRunOutDir(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(t.TempDir(), "pdf")
+	writeFiles(t, dir, "a.xlsx", "sub/deep/b.docx")
+
+	backend := newFakeBackend()
+	result, err := run([]string{dir}, backend.newConverter, runOptions{OutDir: out})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Converted != 2 {
+		t.Fatalf("result = %+v, want 2 converted", result)
+	}
+
+	for _, name := range []string{"a.pdf", "sub/deep/b.pdf"} {
+		if _, err := os.Stat(filepath.Join(out, filepath.FromSlash(name))); err != nil {
+			t.Errorf("PDF not created in output directory: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err == nil {
+			t.Errorf("PDF %s created next to the source", name)
+		}
+	}
+}
+
+func TestRunSheetDir(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(t.TempDir(), "pdf")
+	writeFiles(t, dir, "budget.xlsx")
+
+	tmpl, err := parseNameTemplate("s_{sheet}/{name}.pdf", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := newFakeBackend()
+	result, err := run([]string{dir}, backend.newConverter, runOptions{OutDir: out, NameTemplate: tmpl, SplitSheets: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Converted != 1 || len(result.Errs) != 0 {
+		t.Fatalf("result = %+v, want 1 converted", result)
+	}
+
+	// シート名を含むフォルダに出力し、{sheet} のままのフォルダは作成しない。
+	if _, err := os.Stat(filepath.Join(out, "s_Sheet1", "budget.pdf")); err != nil {
+		t.Errorf("PDF not created in the sheet directory: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "s_{sheet}")); err == nil {
+		t.Error("directory s_{sheet} created")
+	}
+}
+
+func TestRunMissingTarget(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.xlsx", "b.docx")
+	missing := filepath.Join(dir, "gone.docx")
+
+	// 存在しない対象は失敗とし、他の対象の変換を続ける。
+	backend := newFakeBackend()
+	targets := []string{filepath.Join(dir, "a.xlsx"), missing, filepath.Join(dir, "b.docx")}
+	result, err := run(targets, backend.newConverter, runOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 3 || result.Converted != 2 || result.Failed() != 1 {
+		t.Fatalf("result = %+v, want 2 converted and 1 failed", result)
+	}
+	var fe *FileError
+	if len(result.Errs) != 1 || !errors.As(result.Errs[0], &fe) || fe.Path != missing || !errors.Is(fe, os.ErrNotExist) {
+		t.Errorf("Errs = %v, want %s not to exist", result.Errs, missing)
+	}
+	for _, r := range result.Files {
+		if r.Source == missing && (r.Status != statusFailed || r.Type != "Word" || r.Error == "") {
+			t.Errorf("report = %+v, want failed", r)
+		}
+	}
+}
+
+func TestRunSkippedByConverter(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.xlsx", "b.xlsx")
+
+	// 全てのシートが対象外のブックは、失敗ではなくスキップとする。
+	backend := newFakeBackend()
+	backend.skip["a.xlsx"] = skipNoSheets
+	result, err := run([]string{dir}, backend.newConverter, runOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Converted != 1 || result.Skipped != 1 || result.Failed() != 0 || len(result.Errs) != 0 {
+		t.Fatalf("result = %+v, want 1 converted and 1 skipped", result)
+	}
+	for _, r := range result.Files {
+		if filepath.Base(r.Source) == "a.xlsx" && (r.Status != statusSkipped || r.Reason != skipNoSheets) {
+			t.Errorf("report = %+v, want skipped", r)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.pdf")); err == nil {
+		t.Error("PDF created for the skipped book")
+	}
+}
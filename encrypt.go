@@ -0,0 +1,140 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// isEncrypted は path がパスワードで暗号化されたファイルの場合に true を返す。
+// 暗号化されたファイルを開くと、Officeがパスワードの入力を待って応答しなくなるため、
+// Officeを起動する前にファイルの内容で判定する。
+//   - OOXML 形式 (.xlsx, .docx, .pptx など) は、暗号化すると EncryptionInfo ストリームを含む OLE 複合ファイルになる。
+//   - 旧形式 (.xls, .doc, .ppt) は、ファイルの種類ごとの暗号化のフラグで判定する。
+//   - OpenDocument 形式は、manifest.xml の暗号化の情報で判定する。
+func isEncrypted(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	header := make([]byte, len(cfbSignature))
+	if _, err := io.ReadFull(f, header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	switch {
+	case bytes.HasPrefix(header, zipSignature):
+		zr, err := zip.NewReader(f, info.Size())
+		if err != nil {
+			return false, err
+		}
+		return isODFEncrypted(zr)
+	case bytes.Equal(header, cfbSignature):
+		cf, err := openCFB(f, info.Size())
+		if err != nil {
+			return false, err
+		}
+		return isCFBEncrypted(cf)
+	}
+	return false, nil
+}
+
+// isODFEncrypted は OpenDocument 形式のファイルが暗号化されている場合に true を返す。
+func isODFEncrypted(zr *zip.Reader) (bool, error) {
+	f, err := zr.Open("META-INF/manifest.xml")
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return false, err
+	}
+	return strings.Contains(string(data), "encryption-data"), nil
+}
+
+// 旧形式の暗号化の判定に使用する値。
+const (
+	// wordFlagEncrypted は Word の FIB のフラグ (オフセット 0x0A) の fEncrypted。
+	wordFlagEncrypted = 0x0100
+	// biffFilePass は Excel の BIFF の FILEPASS レコード。ブックが暗号化されている場合に BOF の後に現れる。
+	biffFilePass = 0x002F
+	// biffEOF は Excel の BIFF の EOF レコード。
+	biffEOF = 0x000A
+	// pptEncryptedToken は PowerPoint の Current User ストリームの、暗号化されている場合の headerToken。
+	pptEncryptedToken = 0xF3D1C4DF
+)
+
+// isCFBEncrypted は OLE 複合ファイルが暗号化されている場合に true を返す。
+func isCFBEncrypted(cf *cfbFile) (bool, error) {
+	if _, ok := cf.stream("EncryptionInfo"); ok {
+		return true, nil
+	}
+
+	if e, ok := cf.stream("WordDocument"); ok {
+		fib, err := cf.readStream(e, 12)
+		if err != nil {
+			return false, err
+		}
+		if len(fib) < 12 {
+			return false, errInvalidCFB
+		}
+		return binary.LittleEndian.Uint16(fib[0x0A:])&wordFlagEncrypted != 0, nil
+	}
+
+	for _, name := range []string{"Workbook", "Book"} {
+		if e, ok := cf.stream(name); ok {
+			// FILEPASS はブックの先頭付近にあるため、最初のサブストリームのみを確認する。
+			data, err := cf.readStream(e, 1<<16)
+			if err != nil {
+				return false, err
+			}
+			for i := 0; i+4 <= len(data); {
+				typ := binary.LittleEndian.Uint16(data[i:])
+				size := int(binary.LittleEndian.Uint16(data[i+2:]))
+				switch typ {
+				case biffFilePass:
+					return true, nil
+				case biffEOF:
+					return false, nil
+				}
+				i += 4 + size
+			}
+			return false, nil
+		}
+	}
+
+	if _, ok := cf.stream("PowerPoint Document"); ok {
+		if _, ok := cf.stream("EncryptedSummary"); ok {
+			return true, nil
+		}
+		if e, ok := cf.stream("Current User"); ok {
+			data, err := cf.readStream(e, 16)
+			if err != nil {
+				return false, err
+			}
+			if len(data) < 16 {
+				return false, errInvalidCFB
+			}
+			return binary.LittleEndian.Uint32(data[12:]) == pptEncryptedToken, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+)
+
+// biffRecords は BIFF のレコード (種類, サイズ) を並べたストリームを作成する。
+func biffRecords(records ...uint16) []byte {
+	var buf []byte
+	for i := 0; i+1 < len(records); i += 2 {
+		rec := make([]byte, 4+int(records[i+1]))
+		binary.LittleEndian.PutUint16(rec, records[i])
+		binary.LittleEndian.PutUint16(rec[2:], records[i+1])
+		buf = append(buf, rec...)
+	}
+	return buf
+}
+
+// wordFib は指定したフラグを持つ Word の FIB の先頭部分を作成する。size バイトに拡張する。
+func wordFib(flags uint16, size int) []byte {
+	fib := make([]byte, size)
+	binary.LittleEndian.PutUint16(fib, 0xA5EC)
+	binary.LittleEndian.PutUint16(fib[0x0A:], flags)
+	return fib
+}
+
+// pptCurrentUser は headerToken を持つ PowerPoint の Current User ストリームを作成する。
+func pptCurrentUser(token uint32) []byte {
+	data := make([]byte, 24)
+	binary.LittleEndian.PutUint16(data[2:], 0x0FF6)
+	binary.LittleEndian.PutUint32(data[4:], 16)
+	binary.LittleEndian.PutUint32(data[8:], 20)
+	binary.LittleEndian.PutUint32(data[12:], token)
+	return data
+}
+
+func TestIsEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	const bof, bofSize = 0x0809, 16
+
+	writeCFB(t, filepath.Join(dir, "secret.xlsx"), map[string][]byte{
+		"EncryptionInfo":   make([]byte, 200),
+		"EncryptedPackage": make([]byte, 5000),
+	})
+	writeCFB(t, filepath.Join(dir, "secret.doc"), map[string][]byte{"WordDocument": wordFib(wordFlagEncrypted, 5000), "1Table": nil})
+	writeCFB(t, filepath.Join(dir, "plain.doc"), map[string][]byte{"WordDocument": wordFib(0, 100), "1Table": nil})
+	writeCFB(t, filepath.Join(dir, "secret.xls"), map[string][]byte{"Workbook": biffRecords(bof, bofSize, 0x00E1, 2, biffFilePass, 54, biffEOF, 0)})
+	writeCFB(t, filepath.Join(dir, "plain.xls"), map[string][]byte{"Workbook": biffRecords(bof, bofSize, 0x00E1, 2, biffEOF, 0, biffFilePass, 54)})
+	writeCFB(t, filepath.Join(dir, "secret.ppt"), map[string][]byte{"PowerPoint Document": nil, "Current User": pptCurrentUser(pptEncryptedToken)})
+	writeCFB(t, filepath.Join(dir, "summary.ppt"), map[string][]byte{"PowerPoint Document": nil, "EncryptedSummary": nil})
+	writeCFB(t, filepath.Join(dir, "plain.ppt"), map[string][]byte{"PowerPoint Document": nil, "Current User": pptCurrentUser(0xE391C05F)})
+	writeZip(t, filepath.Join(dir, "secret.odt"), map[string]string{
+		"mimetype":              "application/vnd.oasis.opendocument.text",
+		"META-INF/manifest.xml": `<manifest:manifest><manifest:file-entry manifest:full-path="content.xml"><manifest:encryption-data/></manifest:file-entry></manifest:manifest>`,
+	})
+	writeZip(t, filepath.Join(dir, "plain.xlsx"), map[string]string{"xl/workbook.xml": testWorkbookXML})
+	writeFiles(t, dir, "empty.docx")
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"secret.xlsx", true},
+		{"secret.doc", true},
+		{"plain.doc", false},
+		{"secret.xls", true},
+		{"plain.xls", false},
+		{"secret.ppt", true},
+		{"summary.ppt", true},
+		{"plain.ppt", false},
+		{"secret.odt", true},
+		{"plain.xlsx", false},
+		{"empty.docx", false},
+	}
+	for _, tt := range tests {
+		got, err := isEncrypted(filepath.Join(dir, tt.name))
+		if err != nil {
+			t.Errorf("isEncrypted(%q): %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("isEncrypted(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRunSkipsEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	writeCFB(t, filepath.Join(dir, "secret.xlsx"), map[string][]byte{
+		"EncryptionInfo":   make([]byte, 200),
+		"EncryptedPackage": make([]byte, 5000),
+	})
+	writeFiles(t, dir, "plain.xlsx")
+
+	backend := newFakeBackend()
+	result, err := run([]string{dir}, backend.newConverter, runOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Converted != 1 || result.Skipped != 1 {
+		t.Errorf("result = %+v, want 1 converted and 1 skipped", result)
+	}
+	for _, r := range result.Files {
+		if filepath.Base(r.Source) == "secret.xlsx" && (r.Status != statusSkipped || r.Reason != skipPasswordProtected) {
+			t.Errorf("report = %+v, want skipped as password protected", r)
+		}
+	}
+	for _, event := range backend.eventsOf(AppExcel) {
+		if event == "convert secret.xlsx" {
+			t.Error("encrypted file was passed to the converter")
+		}
+	}
+}
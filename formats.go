@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// exportOptions はPDF出力時の指定。形式ごとに formatSpec で指定する。
+// -backend libreoffice では使用しない。
+type exportOptions struct {
+	// Quality はPDFの品質。standard (印刷向け) または minimum (画面表示向け)。空の場合は standard。
+	Quality string `json:"quality,omitempty"`
+	// IncludeDocProperties が true の場合、ドキュメントのプロパティをPDFに含める。
+	IncludeDocProperties bool `json:"includeDocProperties,omitempty"`
+	// IgnorePrintAreas が true の場合、Excelの印刷範囲を無視してシート全体を出力する。
+	IgnorePrintAreas bool `json:"ignorePrintAreas,omitempty"`
+	// PrintHiddenSlides が true の場合、PowerPointの非表示スライドも出力する。
+	PrintHiddenSlides bool `json:"printHiddenSlides,omitempty"`
+	// SplitSheets が true の場合、Excelのシートごとに別のPDFに出力する。-split-sheets で指定する。
+	SplitSheets bool `json:"-"`
+}
+
+// PDFの品質 (exportOptions.Quality)。
+const (
+	qualityStandard = "standard"
+	qualityMinimum  = "minimum"
+)
+
+// formatSpec は変換元ファイルの形式ごとの、PDF変換に使用するアプリケーションと出力の指定。
+type formatSpec struct {
+	App    AppType
+	Export exportOptions
+}
+
+// formatRegistry は拡張子 (小文字、. を含む) ごとの形式。
+type formatRegistry map[string]formatSpec
+
+// newFormatRegistry は標準で対応する形式を登録した formatRegistry を返す。
+func newFormatRegistry() formatRegistry {
+	return formatRegistry{
+		".xlsx": {App: AppExcel},
+		".xlsm": {App: AppExcel},
+		".xlsb": {App: AppExcel},
+		".xls":  {App: AppExcel},
+		".ods":  {App: AppExcel},
+		".docx": {App: AppWord},
+		".docm": {App: AppWord},
+		".dotx": {App: AppWord},
+		".doc":  {App: AppWord},
+		".rtf":  {App: AppWord},
+		".odt":  {App: AppWord},
+		".pptx": {App: AppPowerPoint},
+		".pptm": {App: AppPowerPoint},
+		".ppsx": {App: AppPowerPoint},
+		".ppt":  {App: AppPowerPoint},
+		".pps":  {App: AppPowerPoint},
+		".odp":  {App: AppPowerPoint},
+	}
+}
+
+// formats はPDF変換対象とする形式。-formats で指定された設定ファイルで追加・変更する。
+var formats = newFormatRegistry()
+
+// lookup は path の拡張子の形式を返す。拡張子の大文字と小文字は区別しない。
+func (r formatRegistry) lookup(path string) (formatSpec, bool) {
+	spec, ok := r[strings.ToLower(filepath.Ext(path))]
+	return spec, ok
+}
+
+// exportOptionsFor は app で変換する path のPDF出力の指定を返す。
+// 拡張子と異なるアプリケーションで変換する場合は、標準の指定とする。
+func (r formatRegistry) exportOptionsFor(app AppType, path string) exportOptions {
+	if spec, ok := r.lookup(path); ok && spec.App == app {
+		return spec.Export
+	}
+	return exportOptions{}
+}
+
+// formatConfig は設定ファイルに記述する1形式分の指定。
+type formatConfig struct {
+	// App は excel、word、powerpoint のいずれか。
+	App string `json:"app"`
+	exportOptions
+}
+
+// load は path の設定ファイル (JSON) を読み込み、形式を追加または変更する。
+// 設定ファイルは拡張子をキーとするオブジェクトで、例えば次のように記述する。
+//
+//	{
+//	  ".xltx": {"app": "excel"},
+//	  ".pptx": {"app": "powerpoint", "quality": "minimum", "printHiddenSlides": true}
+//	}
+func (r formatRegistry) load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var config map[string]formatConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return err
+	}
+
+	specs := formatRegistry{}
+	for ext, c := range config {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		if ext == "." || strings.ContainsAny(ext[1:], `./\`) {
+			return fmt.Errorf("拡張子の指定が正しくありません: %s", ext)
+		}
+		app, err := parseAppType(c.App)
+		if err != nil {
+			return fmt.Errorf("%s: %w", ext, err)
+		}
+		switch c.Quality {
+		case "", qualityStandard, qualityMinimum:
+		default:
+			return fmt.Errorf("%s: quality の指定が正しくありません: %s", ext, c.Quality)
+		}
+		specs[ext] = formatSpec{App: app, Export: c.exportOptions}
+	}
+	for ext, spec := range specs {
+		r[ext] = spec
+	}
+	return nil
+}
+
+// parseAppType はアプリケーション名 (excel、word、powerpoint) を AppType に変換する。
+// 大文字と小文字は区別しない。
+func parseAppType(name string) (AppType, error) {
+	for _, app := range []AppType{AppExcel, AppWord, AppPowerPoint} {
+		if strings.EqualFold(name, app.String()) {
+			return app, nil
+		}
+	}
+	return 0, fmt.Errorf("アプリケーションの指定が正しくありません: %q", name)
+}
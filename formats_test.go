@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetFilePathsFormats(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir,
+		"a.xlsm", "b.xlsb", "c.ods",
+		"d.docm", "e.dotx", "f.doc", "g.rtf", "h.odt",
+		"i.pptm", "j.ppsx", "k.pps", "l.odp",
+		"memo.txt", "data.csv",
+	)
+
+	xls, doc, ppt, err := getFilePaths(dir, walkOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(xls) != 3 || len(doc) != 5 || len(ppt) != 4 {
+		t.Errorf("getFilePaths = %v, %v, %v", xls, doc, ppt)
+	}
+}
+
+func TestFormatRegistryLoad(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "formats.json")
+	if err := os.WriteFile(config, []byte(`{
+  "xltx": {"app": "excel"},
+  ".PPTX": {"app": "PowerPoint", "quality": "minimum", "printHiddenSlides": true}
+}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := newFormatRegistry()
+	if err := r.load(config); err != nil {
+		t.Fatal(err)
+	}
+	if spec, ok := r.lookup("template.XLTX"); !ok || spec.App != AppExcel {
+		t.Errorf("lookup(.XLTX) = %+v, %v, want Excel", spec, ok)
+	}
+	want := exportOptions{Quality: qualityMinimum, PrintHiddenSlides: true}
+	if got := r.exportOptionsFor(AppPowerPoint, "slides.pptx"); got != want {
+		t.Errorf("exportOptionsFor(pptx) = %+v, want %+v", got, want)
+	}
+	// 拡張子と異なるアプリケーションで変換する場合は、標準の指定とする。
+	if got := r.exportOptionsFor(AppWord, "slides.pptx"); got != (exportOptions{}) {
+		t.Errorf("exportOptionsFor(Word, pptx) = %+v, want default", got)
+	}
+	if spec, _ := r.lookup("book.xlsx"); spec.App != AppExcel {
+		t.Errorf("lookup(.xlsx) = %+v, want default Excel", spec)
+	}
+
+	for _, bad := range []string{
+		`{".xyz": {"app": "access"}}`,
+		`{".xyz": {"app": "excel", "quality": "high"}}`,
+		`{".": {"app": "excel"}}`,
+		`[".xyz"]`,
+	} {
+		if err := os.WriteFile(config, []byte(bad), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := newFormatRegistry().load(config); err == nil {
+			t.Errorf("load(%s): want error", bad)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package main
+
+import "io/fs"
+
+// info に隠し属性またはシステム属性が設定されている場合に true を返す。Windows以外には属性が無い。
+func hasHiddenAttribute(info fs.FileInfo) bool {
+	return false
+}
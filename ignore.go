@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// ignoreFileName は、フォルダごとに変換対象外とするファイルを記述するファイルの名前。
+// 書式は .gitignore と同じで、記述したフォルダとそのサブフォルダに適用する。
+const ignoreFileName = ".office2pdfignore"
+
+// ignorePattern は .gitignore 形式のパターン1行分。
+type ignorePattern struct {
+	// base はパターンを記述したフォルダの、対象フォルダからの相対パス (/ 区切り)。
+	base    string
+	negate  bool
+	dirOnly bool
+	re      *regexp.Regexp
+}
+
+// compileIgnorePattern は .gitignore 形式のパターンを解析する。
+// / を含むパターンは base からの相対パス、含まないパターンはファイル名と比較する。
+// 空行とコメントの場合は nil を返す。
+func compileIgnorePattern(line, base string) (*ignorePattern, error) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, nil
+	}
+
+	p := &ignorePattern{base: base}
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		// \! や \# で始まるパターン
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return nil, nil
+	}
+
+	anchored := strings.Contains(line, "/")
+	expr := globToRegexp(strings.TrimPrefix(line, "/"))
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+	// Windows のファイル名は大文字と小文字を区別しない。
+	if runtime.GOOS == "windows" {
+		expr = "(?i)" + expr
+	}
+
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return nil, err
+	}
+	p.re = re
+	return p, nil
+}
+
+// match は対象フォルダからの相対パス rel (/ 区切り) がパターンに一致するかを返す。
+func (p *ignorePattern) match(rel string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	if p.base != "" {
+		if !strings.HasPrefix(rel, p.base+"/") {
+			return false
+		}
+		rel = rel[len(p.base)+1:]
+	}
+	return p.re.MatchString(rel)
+}
+
+// globToRegexp はグロブを正規表現に変換する。
+// * と ? は / 以外に、** は / を含む任意の文字列に一致する。
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			j := strings.IndexByte(glob[i+1:], ']')
+			if j < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+j]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += j + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// pathFilter は -include、-exclude と .office2pdfignore により、変換対象のファイルを絞り込む。
+type pathFilter struct {
+	root    string
+	include []*ignorePattern
+	exclude []*ignorePattern
+	// rules はフォルダ (対象フォルダからの相対パス) ごとの .office2pdfignore のパターン。
+	rules map[string][]*ignorePattern
+}
+
+// newPathFilter は root を対象フォルダとする pathFilter を作成する。
+// include が空の場合は、全てのファイルを対象とする。
+func newPathFilter(root string, include, exclude []string) (*pathFilter, error) {
+	f := &pathFilter{root: root, rules: map[string][]*ignorePattern{}}
+	for _, glob := range include {
+		p, err := compileIgnorePattern(glob, "")
+		if err != nil {
+			return nil, err
+		}
+		if p != nil {
+			f.include = append(f.include, p)
+		}
+	}
+	for _, glob := range exclude {
+		p, err := compileIgnorePattern(glob, "")
+		if err != nil {
+			return nil, err
+		}
+		if p != nil {
+			f.exclude = append(f.exclude, p)
+		}
+	}
+	return f, nil
+}
+
+// loadIgnoreFile は dir に .office2pdfignore があれば読み込む。
+// フォルダに入る時、そのフォルダのファイルを判定する前に呼び出す。
+func (f *pathFilter) loadIgnoreFile(dir string) error {
+	file, err := os.Open(filepath.Join(dir, ignoreFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	base, err := f.rel(dir)
+	if err != nil {
+		return err
+	}
+	if base == "." {
+		base = ""
+	}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		p, err := compileIgnorePattern(scanner.Text(), base)
+		if err != nil {
+			return err
+		}
+		if p != nil {
+			f.rules[base] = append(f.rules[base], p)
+		}
+	}
+	return scanner.Err()
+}
+
+// excluded は path を変換対象外とする場合に true を返す。
+// -exclude、上位のフォルダから順に .office2pdfignore のパターンを評価し、最後に一致したパターンで決める。
+func (f *pathFilter) excluded(path string, isDir bool) bool {
+	rel, err := f.rel(path)
+	if err != nil || rel == "." {
+		return false
+	}
+
+	ignored := false
+	for _, p := range f.exclude {
+		if p.match(rel, isDir) {
+			ignored = !p.negate
+		}
+	}
+
+	var dirs []string
+	for dir := filepath.ToSlash(filepath.Dir(filepath.FromSlash(rel))); ; dir = filepath.ToSlash(filepath.Dir(filepath.FromSlash(dir))) {
+		if dir == "." {
+			dirs = append(dirs, "")
+			break
+		}
+		dirs = append(dirs, dir)
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		for _, p := range f.rules[dirs[i]] {
+			if p.match(rel, isDir) {
+				ignored = !p.negate
+			}
+		}
+	}
+	if ignored || isDir || len(f.include) == 0 {
+		return ignored
+	}
+
+	for _, p := range f.include {
+		if p.match(rel, false) && !p.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// rel は path の、対象フォルダからの相対パスを / 区切りで返す。
+func (f *pathFilter) rel(path string) (string, error) {
+	rel, err := filepath.Rel(f.root, path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
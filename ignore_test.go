@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIgnorePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		base    string
+		rel     string
+		isDir   bool
+		want    bool
+	}{
+		{"*.xlsx", "", "a.xlsx", false, true},
+		{"*.xlsx", "", "sub/a.xlsx", false, true},
+		{"*.xlsx", "", "a.docx", false, false},
+		{"old/", "", "old", true, true},
+		{"old/", "", "sub/old", true, true},
+		{"old/", "", "old", false, false},
+		{"/old", "", "sub/old", true, false},
+		{"reports/*.docx", "", "reports/a.docx", false, true},
+		{"reports/*.docx", "", "reports/x/a.docx", false, false},
+		{"reports/**/*.docx", "", "reports/x/y/a.docx", false, true},
+		{"**/tmp", "", "a/b/tmp", true, true},
+		{"data?.xls", "", "data1.xls", false, true},
+		{"[!a]*.pptx", "", "b.pptx", false, true},
+		{"[!a]*.pptx", "", "a.pptx", false, false},
+		{"*.xlsx", "sub", "a.xlsx", false, false},
+		{"*.xlsx", "sub", "sub/a.xlsx", false, true},
+		{`\#memo.docx`, "", "#memo.docx", false, true},
+	}
+
+	for _, tt := range tests {
+		p, err := compileIgnorePattern(tt.pattern, tt.base)
+		if err != nil {
+			t.Errorf("compileIgnorePattern(%q) error: %v", tt.pattern, err)
+			continue
+		}
+		if got := p.match(tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("%q (base %q).match(%q, %v) = %v, want %v", tt.pattern, tt.base, tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+
+	for _, line := range []string{"", "   ", "# comment"} {
+		if p, err := compileIgnorePattern(line, ""); p != nil || err != nil {
+			t.Errorf("compileIgnorePattern(%q) = %v, %v, want nil", line, p, err)
+		}
+	}
+}
+
+func TestGetFilePathsFilter(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir,
+		"a.xlsx", "~$a.xlsx",
+		"old/b.xlsx",
+		"sub/c.docx", "sub/tmpl_c.docx",
+		"archive/d.pptx",
+		"archive/keep/e.xlsx",
+	)
+	writeIgnoreFile := func(rel, content string) {
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(rel), ignoreFileName), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeIgnoreFile(".", "# 過去の資料\narchive/\n")
+	writeIgnoreFile("sub", "tmpl_*\n")
+
+	tests := []struct {
+		include, exclude []string
+		want             []string
+	}{
+		{nil, []string{"old/"}, []string{"a.xlsx", "sub/c.docx"}},
+		{[]string{"*.docx"}, nil, []string{"sub/c.docx"}},
+		{nil, nil, []string{"a.xlsx", "old/b.xlsx", "sub/c.docx"}},
+	}
+
+	for _, tt := range tests {
+		filter, err := newPathFilter(dir, tt.include, tt.exclude)
+		if err != nil {
+			t.Fatal(err)
+		}
+		xls, doc, ppt, err := getFilePaths(dir, walkOptions{Filter: filter})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, path := range append(append(xls, doc...), ppt...) {
+			rel, _ := filepath.Rel(dir, path)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("include %v, exclude %v: got %v, want %v", tt.include, tt.exclude, got, tt.want)
+		}
+	}
+}
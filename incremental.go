@@ -0,0 +1,165 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"Office2PDF/internal/ooxml"
+)
+
+// isPdfUpToDate は pdfs が全て存在し、変換元の src より新しい場合に true を返す。
+// pdfs が空の場合は false を返す。
+func isPdfUpToDate(src string, pdfs ...string) (bool, error) {
+	pdfTime, ok, err := pdfModTime(pdfs)
+	if err != nil || !ok {
+		return false, err
+	}
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return false, err
+	}
+	return !pdfTime.Before(srcInfo.ModTime()), nil
+}
+
+// pdfModTime は出力済みのPDFのうち、最も古い更新日時を返す。
+// pdfs が空の場合と、存在しないPDFがある場合は ok が false となる。
+func pdfModTime(pdfs []string) (t time.Time, ok bool, err error) {
+	for _, pdf := range pdfs {
+		info, err := os.Stat(pdf)
+		if errors.Is(err, fs.ErrNotExist) {
+			return time.Time{}, false, nil
+		}
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		if !ok || info.ModTime().Before(t) {
+			t, ok = info.ModTime(), true
+		}
+	}
+	return t, ok, nil
+}
+
+// expectedPdfs は src を変換した時に出力されるPDFのパスを返す。
+// target に {sheet} がある場合は、ブックのシートを読み取り、sheets で選択されるシートごとのパスを
+// 変換時と同じ規則で返す。OOXML 形式以外などで読み取れない場合は空を返す。
+func expectedPdfs(src, target string, sheets *sheetSelector) []string {
+	if !strings.Contains(target, sheetMarker) {
+		return []string{target}
+	}
+	f, err := ooxml.Open(src)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	list, err := f.Sheets()
+	if err != nil {
+		return nil
+	}
+
+	var pdfs []string
+	used := map[string]bool{}
+	for _, s := range list {
+		if (sheets.excludesHidden() && s.Hidden()) || !sheets.selected(s.Name) {
+			continue
+		}
+		pdfs = append(pdfs, sheetPdfPath(target, s.Name, used))
+	}
+	return pdfs
+}
+
+// manifest はPDFに変換した時点の、変換元ファイルの内容のハッシュ値を記録する。
+// 更新日時が信用できない共有フォルダでも、内容が変わったファイルだけを変換できる。
+type manifest struct {
+	path string
+
+	mu sync.Mutex
+	// Files は変換元ファイルの絶対パスごとの SHA-256 のハッシュ値。
+	Files map[string]string `json:"files"`
+}
+
+// loadManifest は path のマニフェストファイルを読み込む。
+// ファイルが存在しない場合は、空のマニフェストを返す。
+func loadManifest(path string) (*manifest, error) {
+	m := &manifest{path: path, Files: map[string]string{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	if m.Files == nil {
+		m.Files = map[string]string{}
+	}
+	return m, nil
+}
+
+// upToDate は pdfs が全て存在し、src の内容が前回の変換から変わっていない場合に true を返す。
+// 変換後に record に渡す src のハッシュ値も返す。
+func (m *manifest) upToDate(src string, pdfs ...string) (ok bool, hash string, err error) {
+	hash, err = hashFile(src)
+	if err != nil {
+		return false, "", err
+	}
+	if _, ok, err := pdfModTime(pdfs); err != nil || !ok {
+		return false, hash, nil
+	}
+
+	key, err := filepath.Abs(src)
+	if err != nil {
+		return false, "", err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.Files[key] == hash, hash, nil
+}
+
+// record は src をハッシュ値 hash の内容でPDFに変換したことを記録する。
+func (m *manifest) record(src, hash string) error {
+	key, err := filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Files[key] = hash
+	return nil
+}
+
+// save はマニフェストをファイルに書き込む。
+func (m *manifest) save() error {
+	m.mu.Lock()
+	data, err := json.MarshalIndent(m, "", "  ")
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.path, data, 0o644)
+}
+
+// path のファイルの内容の SHA-256 を16進数の文字列で返す。
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRunIncremental(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.docx", "b.docx")
+
+	backend := newFakeBackend()
+	opts := runOptions{Incremental: true}
+	if _, err := run([]string{dir}, backend.newConverter, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	// b.docx だけをPDFより新しくする。
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "b.docx"), future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	backend = newFakeBackend()
+	result, err := run([]string{dir}, backend.newConverter, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Converted != 1 || result.Skipped != 1 || result.Failed() != 0 {
+		t.Errorf("result = %+v, want 1 converted and 1 skipped", result)
+	}
+	want := []string{"open", "convert b.docx", "quit"}
+	if got := backend.eventsOf(AppWord); !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestRunIncrementalManifest(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.xlsx", "b.xlsx")
+	manifestPath := filepath.Join(t.TempDir(), "manifest.json")
+
+	runWithManifest := func() *fakeBackend {
+		t.Helper()
+		m, err := loadManifest(manifestPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		backend := newFakeBackend()
+		if _, err := run([]string{dir}, backend.newConverter, runOptions{Incremental: true, Manifest: m}); err != nil {
+			t.Fatal(err)
+		}
+		return backend
+	}
+
+	runWithManifest()
+
+	// a.xlsx は更新日時だけを、b.xlsx は内容を変更する。
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "a.xlsx"), future, future); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.xlsx"), []byte("changed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	backend := runWithManifest()
+	want := []string{"open", "convert b.xlsx", "quit"}
+	if got := backend.eventsOf(AppExcel); !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestUpToDateSplitSheets(t *testing.T) {
+	dir := t.TempDir()
+	writeZip(t, filepath.Join(dir, "budget.xlsx"), map[string]string{"xl/workbook.xml": testWorkbookXML})
+	src := filepath.Join(dir, "budget.xlsx")
+	target := filepath.Join(dir, "budget__{sheet}.pdf")
+	if err := os.Chtimes(src, testTime, testTime); err != nil {
+		t.Fatal(err)
+	}
+	opts := runOptions{Sheets: &sheetSelector{Prefix: "_", SkipHidden: true}}
+
+	if ok, _, err := opts.upToDate(src, target); err != nil || ok {
+		t.Errorf("upToDate without PDFs = %v, %v, want false", ok, err)
+	}
+
+	// 出力するシートのうち、1つでもPDFが無ければ変換し直す。
+	writeFiles(t, dir, "budget__Summary.pdf")
+	if ok, _, err := opts.upToDate(src, target); err != nil || ok {
+		t.Errorf("upToDate with a missing PDF = %v, %v, want false", ok, err)
+	}
+
+	writeFiles(t, dir, "budget__Detail.pdf")
+	if ok, _, err := opts.upToDate(src, target); err != nil || !ok {
+		t.Errorf("upToDate = %v, %v, want true", ok, err)
+	}
+
+	// 1つでも古いシートのPDFがあれば、変換し直す。
+	old := testTime.Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "budget__Detail.pdf"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _, err := opts.upToDate(src, target); err != nil || ok {
+		t.Errorf("upToDate with an old PDF = %v, %v, want false", ok, err)
+	}
+}
+
+func TestUpToDateSplitSheetsSharedPrefix(t *testing.T) {
+	// a.xlsx の出力先 a__{sheet}.pdf は、a__b.xlsx のシートのPDF a__b__S1.pdf にも一致する名前になる。
+	dir := t.TempDir()
+	workbook := `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheets><sheet name="S1" sheetId="1"/></sheets></workbook>`
+	for _, name := range []string{"a.xlsx", "a__b.xlsx"} {
+		path := filepath.Join(dir, name)
+		writeZip(t, path, map[string]string{"xl/workbook.xml": workbook})
+		if err := os.Chtimes(path, testTime, testTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFiles(t, dir, "a__b__S1.pdf")
+
+	opts := runOptions{}
+	src := filepath.Join(dir, "a.xlsx")
+	target := filepath.Join(dir, "a__{sheet}.pdf")
+	if ok, _, err := opts.upToDate(src, target); err != nil || ok {
+		t.Errorf("upToDate with another book's PDF = %v, %v, want false", ok, err)
+	}
+	if ok, _, err := opts.upToDate(filepath.Join(dir, "a__b.xlsx"), filepath.Join(dir, "a__b__{sheet}.pdf")); err != nil || !ok {
+		t.Errorf("upToDate of a__b.xlsx = %v, %v, want true", ok, err)
+	}
+
+	writeFiles(t, dir, "a__S1.pdf")
+	if ok, _, err := opts.upToDate(src, target); err != nil || !ok {
+		t.Errorf("upToDate = %v, %v, want true", ok, err)
+	}
+}
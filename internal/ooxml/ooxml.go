@@ -0,0 +1,123 @@
+// Package ooxml は Office を起動せずに、OOXML 形式 (.xlsx, .docx, .pptx など) のファイルの内容を読み取る。
+// PDF変換の計画で、出力されるシートやスライド、ドキュメントのプロパティを確認するために使用する。
+package ooxml
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// リレーションシップの種類 (Type 属性の末尾)。
+const (
+	relOfficeDocument     = "/officedocument"
+	relCoreProperties     = "/core-properties"
+	relExtendedProperties = "/extended-properties"
+)
+
+// r:id 属性の名前空間。Strict 形式と Transitional 形式で異なる。
+const (
+	nsRelationships       = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	nsStrictRelationships = "http://purl.oclc.org/ooxml/officeDocument/relationships"
+)
+
+// File は開いた OOXML 形式のファイル。
+type File struct {
+	zr *zip.ReadCloser
+}
+
+// Open は path の OOXML 形式のファイルを開く。
+func Open(path string) (*File, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	return &File{zr: zr}, nil
+}
+
+// Close はファイルを閉じる。
+func (f *File) Close() error {
+	return f.zr.Close()
+}
+
+// relationship はパッケージ内のパーツ間の参照。
+type relationship struct {
+	ID     string `xml:"Id,attr"`
+	Type   string `xml:"Type,attr"`
+	Target string `xml:"Target,attr"`
+	Mode   string `xml:"TargetMode,attr"`
+}
+
+// is はリレーションシップの種類が suffix で終わる場合に true を返す。
+// 種類の URI は Strict 形式と Transitional 形式で異なるため、末尾で比較する。
+func (r relationship) is(suffix string) bool {
+	return strings.HasSuffix(strings.ToLower(r.Type), suffix)
+}
+
+// relID は要素の属性から r:id 属性の値を返す。無い場合は空を返す。
+func relID(attrs []xml.Attr) string {
+	for _, a := range attrs {
+		if a.Name.Local == "id" && (a.Name.Space == nsRelationships || a.Name.Space == nsStrictRelationships) {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// decode はパーツ name の XML を v に読み込む。
+func (f *File) decode(name string, v any) error {
+	r, err := f.zr.Open(name)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return xml.NewDecoder(r).Decode(v)
+}
+
+// rels はパーツ part (パッケージの場合は空) のリレーションシップを返す。
+// Target はパッケージ内の絶対パス (先頭の / を除く) にする。リレーションシップが無い場合は空を返す。
+func (f *File) rels(part string) ([]relationship, error) {
+	dir, base := path.Split(part)
+	var list struct {
+		Rels []relationship `xml:"Relationship"`
+	}
+	err := f.decode(dir+"_rels/"+base+".rels", &list)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rels := list.Rels[:0]
+	for _, r := range list.Rels {
+		if strings.EqualFold(r.Mode, "External") {
+			continue
+		}
+		if strings.HasPrefix(r.Target, "/") {
+			r.Target = strings.TrimPrefix(r.Target, "/")
+		} else {
+			r.Target = path.Join(dir, r.Target)
+		}
+		rels = append(rels, r)
+	}
+	return rels, nil
+}
+
+// part はパッケージのリレーションシップから、種類が suffix のパーツの名前を返す。
+// 見つからない場合は fallback を返す。
+func (f *File) part(suffix, fallback string) (string, error) {
+	rels, err := f.rels("")
+	if err != nil {
+		return "", err
+	}
+	for _, r := range rels {
+		if r.is(suffix) {
+			return r.Target, nil
+		}
+	}
+	return fallback, nil
+}
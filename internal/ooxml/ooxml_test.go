@@ -0,0 +1,89 @@
+package ooxml
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// files の内容の OOXML 形式のファイル name を一時フォルダに作成し、開く。
+func openTestFile(t *testing.T, name string, files map[string]string) *File {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(out)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := out.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+// rootRels は main が本体のパーツとなるパッケージのリレーションシップ。
+func rootRels(main string) string {
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="` + main + `"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>
+<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/extended-properties" Target="docProps/app.xml"/>
+</Relationships>`
+}
+
+func TestRels(t *testing.T) {
+	f := openTestFile(t, "book.xlsx", map[string]string{
+		"_rels/.rels": rootRels("/xl/workbook.xml"),
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="https://example.com/" TargetMode="External"/>
+<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/theme" Target="/xl/theme/theme1.xml"/>
+</Relationships>`,
+	})
+
+	part, err := f.part(relOfficeDocument, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if part != "xl/workbook.xml" {
+		t.Errorf("part = %q, want xl/workbook.xml", part)
+	}
+
+	rels, err := f.rels(part)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"xl/worksheets/sheet1.xml", "xl/theme/theme1.xml"}
+	if len(rels) != len(want) {
+		t.Fatalf("rels = %+v, want targets %v", rels, want)
+	}
+	for i, r := range rels {
+		if r.Target != want[i] {
+			t.Errorf("rels[%d].Target = %q, want %q", i, r.Target, want[i])
+		}
+	}
+
+	// リレーションシップが無いパーツは空とする。
+	if rels, err := f.rels("xl/worksheets/sheet1.xml"); err != nil || len(rels) != 0 {
+		t.Errorf("rels without .rels = %+v, %v", rels, err)
+	}
+}
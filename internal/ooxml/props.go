@@ -0,0 +1,55 @@
+package ooxml
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// Properties はドキュメントのプロパティ (docProps/core.xml と docProps/app.xml)。
+// 値は最後に保存したアプリケーションが記録したもので、ファイルに無い項目は空または 0 となる。
+type Properties struct {
+	Title          string
+	Author         string
+	LastModifiedBy string
+	// Application はファイルを保存したアプリケーションの名前。
+	Application string
+	// Pages は Word の文書のページ数、Slides と HiddenSlides は PowerPoint のスライド数。
+	Pages        int
+	Slides       int
+	HiddenSlides int
+}
+
+// Properties はドキュメントのプロパティを返す。
+func (f *File) Properties() (Properties, error) {
+	var p Properties
+
+	corePart, err := f.part(relCoreProperties, "docProps/core.xml")
+	if err != nil {
+		return p, err
+	}
+	var core struct {
+		Title          string `xml:"title"`
+		Creator        string `xml:"creator"`
+		LastModifiedBy string `xml:"lastModifiedBy"`
+	}
+	if err := f.decode(corePart, &core); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return p, err
+	}
+	p.Title, p.Author, p.LastModifiedBy = core.Title, core.Creator, core.LastModifiedBy
+
+	appPart, err := f.part(relExtendedProperties, "docProps/app.xml")
+	if err != nil {
+		return p, err
+	}
+	var app struct {
+		Application  string `xml:"Application"`
+		Pages        int    `xml:"Pages"`
+		Slides       int    `xml:"Slides"`
+		HiddenSlides int    `xml:"HiddenSlides"`
+	}
+	if err := f.decode(appPart, &app); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return p, err
+	}
+	p.Application, p.Pages, p.Slides, p.HiddenSlides = app.Application, app.Pages, app.Slides, app.HiddenSlides
+	return p, nil
+}
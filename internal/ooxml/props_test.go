@@ -0,0 +1,38 @@
+package ooxml
+
+import "testing"
+
+func TestProperties(t *testing.T) {
+	f := openTestFile(t, "report.docx", map[string]string{
+		"_rels/.rels": rootRels("word/document.xml"),
+		"docProps/core.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:title>月次報告</dc:title><dc:creator>山田</dc:creator><cp:lastModifiedBy>佐藤</cp:lastModifiedBy>
+</cp:coreProperties>`,
+		"docProps/app.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties">
+<Application>Microsoft Office Word</Application><Pages>12</Pages>
+</Properties>`,
+	})
+
+	got, err := f.Properties()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Properties{Title: "月次報告", Author: "山田", LastModifiedBy: "佐藤", Application: "Microsoft Office Word", Pages: 12}
+	if got != want {
+		t.Errorf("Properties = %+v, want %+v", got, want)
+	}
+}
+
+func TestPropertiesMissing(t *testing.T) {
+	// プロパティのパーツが無い場合は、空のプロパティとする。
+	f := openTestFile(t, "empty.docx", map[string]string{"word/document.xml": "<document/>"})
+	got, err := f.Properties()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != (Properties{}) {
+		t.Errorf("Properties = %+v, want empty", got)
+	}
+}
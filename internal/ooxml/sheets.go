@@ -0,0 +1,75 @@
+package ooxml
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// シートの種類。
+const (
+	KindWorksheet = "worksheet"
+	KindChart     = "chart"
+	KindDialog    = "dialog"
+	KindMacro     = "macro"
+)
+
+// Sheet はブックのシート。
+type Sheet struct {
+	Name string
+	// State は非表示のシートの場合 hidden または veryHidden。表示されているシートの場合は空。
+	State string
+	// Kind はシートの種類。KindWorksheet、KindChart、KindDialog、KindMacro のいずれか。
+	Kind string
+}
+
+// Hidden はシートが非表示の場合に true を返す。
+func (s Sheet) Hidden() bool {
+	return s.State == "hidden" || s.State == "veryHidden"
+}
+
+// sheetKinds はシートのリレーションシップの種類 (末尾) ごとの、シートの種類。
+var sheetKinds = map[string]string{
+	"/worksheet":    KindWorksheet,
+	"/chartsheet":   KindChart,
+	"/dialogsheet":  KindDialog,
+	"/xlmacrosheet": KindMacro,
+}
+
+// Sheets はExcelのブックのシートを、ブック内の順番で返す。
+// 種類を判定できないシートは KindWorksheet とする。
+func (f *File) Sheets() ([]Sheet, error) {
+	part, err := f.part(relOfficeDocument, "xl/workbook.xml")
+	if err != nil {
+		return nil, err
+	}
+	var workbook struct {
+		Sheets []struct {
+			Name  string     `xml:"name,attr"`
+			State string     `xml:"state,attr"`
+			Attrs []xml.Attr `xml:",any,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := f.decode(part, &workbook); err != nil {
+		return nil, err
+	}
+	rels, err := f.rels(part)
+	if err != nil {
+		return nil, err
+	}
+	kinds := map[string]string{}
+	for _, r := range rels {
+		if i := strings.LastIndex(r.Type, "/"); i >= 0 {
+			kinds[r.ID] = sheetKinds[strings.ToLower(r.Type[i:])]
+		}
+	}
+
+	sheets := make([]Sheet, 0, len(workbook.Sheets))
+	for _, s := range workbook.Sheets {
+		kind := kinds[relID(s.Attrs)]
+		if kind == "" {
+			kind = KindWorksheet
+		}
+		sheets = append(sheets, Sheet{Name: s.Name, State: s.State, Kind: kind})
+	}
+	return sheets, nil
+}
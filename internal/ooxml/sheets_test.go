@@ -0,0 +1,95 @@
+package ooxml
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testWorkbookXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets>
+<sheet name="Summary" sheetId="1" r:id="rId1"/>
+<sheet name="Trend" sheetId="2" r:id="rId2"/>
+<sheet name="Archive" sheetId="3" state="hidden" r:id="rId3"/>
+<sheet name="Macro" sheetId="4" state="veryHidden" r:id="rId4"/>
+</sheets>
+</workbook>`
+
+const testWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chartsheet" Target="chartsheets/sheet1.xml"/>
+<Relationship Id="rId3" Type="http://purl.oclc.org/ooxml/officeDocument/relationships/worksheet" Target="worksheets/sheet2.xml"/>
+<Relationship Id="rId4" Type="http://schemas.microsoft.com/office/2006/relationships/xlMacrosheet" Target="macrosheets/sheet1.xml"/>
+</Relationships>`
+
+func TestSheets(t *testing.T) {
+	f := openTestFile(t, "book.xlsm", map[string]string{
+		"_rels/.rels":                rootRels("xl/workbook.xml"),
+		"xl/workbook.xml":            testWorkbookXML,
+		"xl/_rels/workbook.xml.rels": testWorkbookRels,
+	})
+	got, err := f.Sheets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Sheet{
+		{Name: "Summary", Kind: KindWorksheet},
+		{Name: "Trend", Kind: KindChart},
+		{Name: "Archive", State: "hidden", Kind: KindWorksheet},
+		{Name: "Macro", State: "veryHidden", Kind: KindMacro},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Sheets = %+v, want %+v", got, want)
+	}
+	for _, s := range got {
+		if s.Hidden() != (s.State != "") {
+			t.Errorf("%s: Hidden() = %v", s.Name, s.Hidden())
+		}
+	}
+}
+
+func TestSheetsWithoutRels(t *testing.T) {
+	// リレーションシップが無い場合は、既定の場所のブックを読み、全てワークシートとする。
+	f := openTestFile(t, "book.xlsx", map[string]string{"xl/workbook.xml": testWorkbookXML})
+	got, err := f.Sheets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 4 || got[1].Name != "Trend" || got[1].Kind != KindWorksheet {
+		t.Errorf("Sheets = %+v", got)
+	}
+}
+
+func TestSheetsStrict(t *testing.T) {
+	// Strict 形式のブックは、r:id 属性とリレーションシップの種類の名前空間が異なる。
+	f := openTestFile(t, "book.xlsx", map[string]string{
+		"_rels/.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://purl.oclc.org/ooxml/officeDocument/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`,
+		"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://purl.oclc.org/ooxml/spreadsheetml/main" xmlns:r="http://purl.oclc.org/ooxml/officeDocument/relationships" conformance="strict">
+<sheets>
+<sheet name="Data" sheetId="1" r:id="rId1"/>
+<sheet name="Chart" sheetId="2" state="hidden" r:id="rId2"/>
+</sheets>
+</workbook>`,
+		"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://purl.oclc.org/ooxml/officeDocument/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://purl.oclc.org/ooxml/officeDocument/relationships/chartsheet" Target="chartsheets/sheet1.xml"/>
+</Relationships>`,
+	})
+	got, err := f.Sheets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Sheet{
+		{Name: "Data", Kind: KindWorksheet},
+		{Name: "Chart", State: "hidden", Kind: KindChart},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Sheets = %+v, want %+v", got, want)
+	}
+}
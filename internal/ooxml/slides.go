@@ -0,0 +1,81 @@
+package ooxml
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// Slide はプレゼンテーションのスライド。
+type Slide struct {
+	// Part はスライドのパーツの名前 (例: ppt/slides/slide1.xml)。
+	Part string
+	// Hidden はスライドショーで表示しないスライドの場合に true。
+	Hidden bool
+}
+
+// Slides はPowerPointのプレゼンテーションのスライドを、表示する順番で返す。
+func (f *File) Slides() ([]Slide, error) {
+	part, err := f.part(relOfficeDocument, "ppt/presentation.xml")
+	if err != nil {
+		return nil, err
+	}
+	var presentation struct {
+		IDs []struct {
+			Attrs []xml.Attr `xml:",any,attr"`
+		} `xml:"sldIdLst>sldId"`
+	}
+	if err := f.decode(part, &presentation); err != nil {
+		return nil, err
+	}
+	rels, err := f.rels(part)
+	if err != nil {
+		return nil, err
+	}
+	targets := map[string]string{}
+	for _, r := range rels {
+		targets[r.ID] = r.Target
+	}
+
+	slides := make([]Slide, 0, len(presentation.IDs))
+	for _, id := range presentation.IDs {
+		target, ok := targets[relID(id.Attrs)]
+		if !ok {
+			continue
+		}
+		hidden, err := f.slideHidden(target)
+		if err != nil {
+			return nil, err
+		}
+		slides = append(slides, Slide{Part: target, Hidden: hidden})
+	}
+	return slides, nil
+}
+
+// slideHidden はスライドのパーツ part のルート要素の show 属性が 0 の場合に true を返す。
+// スライド全体を読み込まないように、最初の要素だけを確認する。
+func (f *File) slideHidden(part string) (bool, error) {
+	r, err := f.zr.Open(part)
+	if err != nil {
+		return false, err
+	}
+	defer r.Close()
+
+	d := xml.NewDecoder(r)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			for _, a := range start.Attr {
+				if a.Name.Local == "show" {
+					return a.Value == "0" || a.Value == "false", nil
+				}
+			}
+			return false, nil
+		}
+	}
+}
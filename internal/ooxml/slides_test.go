@@ -0,0 +1,43 @@
+package ooxml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSlides(t *testing.T) {
+	slide := func(attr string) string {
+		return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<p:sld xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"` + attr + `><p:cSld/></p:sld>`
+	}
+	f := openTestFile(t, "deck.pptx", map[string]string{
+		"_rels/.rels": rootRels("ppt/presentation.xml"),
+		"ppt/presentation.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<p:presentation xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<p:sldIdLst><p:sldId id="256" r:id="rId3"/><p:sldId id="257" r:id="rId2"/><p:sldId id="258" r:id="rId4"/></p:sldIdLst>
+</p:presentation>`,
+		"ppt/_rels/presentation.xml.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/slideMaster" Target="slideMasters/slideMaster1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/slide" Target="slides/slide1.xml"/>
+<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/slide" Target="slides/slide2.xml"/>
+<Relationship Id="rId4" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/slide" Target="slides/slide3.xml"/>
+</Relationships>`,
+		"ppt/slides/slide1.xml": slide(""),
+		"ppt/slides/slide2.xml": slide(` show="0"`),
+		"ppt/slides/slide3.xml": slide(` show="1"`),
+	})
+
+	got, err := f.Slides()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Slide{
+		{Part: "ppt/slides/slide2.xml", Hidden: true},
+		{Part: "ppt/slides/slide1.xml"},
+		{Part: "ppt/slides/slide3.xml"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Slides = %+v, want %+v", got, want)
+	}
+}
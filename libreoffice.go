@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/exp/slog"
+)
+
+// sofficeConverter は LibreOffice の soffice コマンドをヘッドレスで実行して、
+// ファイルをPDFに変換する Converter。soffice はファイルごとに起動する。
+// シート名による変換対象外の指定 (-g) には対応していない。
+type sofficeConverter struct {
+	app     AppType
+	command string
+	// profile は soffice のユーザープロファイルを格納するディレクトリ。
+	// 複数の soffice を同時に実行できるように、インスタンスごとに分ける。
+	profile string
+}
+
+// newSofficeConverter は command で指定された soffice を使用する Converter を生成する。
+func newSofficeConverter(app AppType, command string) *sofficeConverter {
+	return &sofficeConverter{app: app, command: command}
+}
+
+// Open は soffice コマンドの存在を確認し、ユーザープロファイルのディレクトリを作成する。
+func (c *sofficeConverter) Open() error {
+	command, err := exec.LookPath(c.command)
+	if err != nil {
+		return err
+	}
+	c.command = command
+
+	c.profile, err = os.MkdirTemp("", "office2pdf-")
+	if err != nil {
+		return err
+	}
+
+	slog.Info("LibreOffice("+c.app.String()+")を起動しました.", "soffice", c.command)
+	return nil
+}
+
+// Convert は soffice --headless --convert-to pdf で src のファイルをPDFに変換し、dst に出力する。
+// ctx が終了した場合は、soffice のプロセスを強制終了する。export は使用しない。
+func (c *sofficeConverter) Convert(ctx context.Context, src, dst string, export exportOptions) (ConvertResult, error) {
+	// soffice は出力ファイル名を指定できないため、作業フォルダに出力してから移動する。
+	outDir, err := os.MkdirTemp(c.profile, "out-")
+	if err != nil {
+		return ConvertResult{}, err
+	}
+	defer os.RemoveAll(outDir)
+
+	cmd := exec.CommandContext(ctx, c.command,
+		"-env:UserInstallation="+fileURL(filepath.Join(c.profile, "user")),
+		"--headless", "--norestore",
+		"--convert-to", "pdf",
+		"--outdir", outDir,
+		src)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return ConvertResult{}, fmt.Errorf("%w: %s: %s", ErrConvertPdf, err.Error(), strings.TrimSpace(string(out)))
+	}
+
+	// soffice は変換に失敗しても終了コード 0 を返すことがあるため、出力ファイルの有無で判定する。
+	pdf := filepath.Join(outDir, getFileNameWithoutExt(src)+".pdf")
+	if _, err := os.Stat(pdf); err != nil {
+		return ConvertResult{}, fmt.Errorf("%w: %s", ErrConvertPdf, strings.TrimSpace(string(out)))
+	}
+
+	return ConvertResult{}, moveFile(pdf, dst)
+}
+
+// Quit はユーザープロファイルのディレクトリを削除する。
+func (c *sofficeConverter) Quit() error {
+	if c.profile == "" {
+		return nil
+	}
+	defer func() { c.profile = "" }()
+
+	if err := os.RemoveAll(c.profile); err != nil {
+		return err
+	}
+	slog.Info("LibreOffice(" + c.app.String() + ")を終了しました.")
+	return nil
+}
+
+// path を soffice の -env:UserInstallation に指定できる file URL に変換する。
+func fileURL(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		// Windows のドライブレター付きのパス (C:/...)
+		path = "/" + path
+	}
+	return "file://" + path
+}
+
+// src のファイルを dst に移動する。
+// ボリュームが異なり名前の変更ができない場合は、コピーしてから削除する。
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := copyFile(src, dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// src のファイルを dst にコピーする。
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// sofficeStub は --outdir にダミーのPDFを出力する soffice の代替スクリプト。
+// ファイル名が broken で始まる場合は、変換に失敗する。
+const sofficeStub = `#!/bin/sh
+outdir=
+while [ $# -gt 1 ]; do
+	case "$1" in
+	--outdir) outdir="$2"; shift ;;
+	esac
+	shift
+done
+name=$(basename "$1")
+case "$name" in
+broken*) echo "Error: source file could not be loaded" >&2; exit 1 ;;
+esac
+printf '%%PDF-1.4 stub\n' > "$outdir/${name%.*}.pdf"
+`
+
+func writeSofficeStub(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("soffice のスタブはシェルスクリプトのため、Windows では実行しない")
+	}
+	path := filepath.Join(t.TempDir(), "soffice")
+	if err := os.WriteFile(path, []byte(sofficeStub), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSofficeConverter(t *testing.T) {
+	stub := writeSofficeStub(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.xlsx", "sub/b.docx", "c.pptx")
+
+	result, err := run([]string{dir}, func(app AppType) Converter {
+		return newSofficeConverter(app, stub)
+	}, runOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Errs) != 0 {
+		t.Fatalf("unexpected errors: %v", result.Errs)
+	}
+
+	for _, name := range []string{"a.pdf", "sub/b.pdf", "c.pdf"} {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Errorf("PDF not created: %v", err)
+			continue
+		}
+		if string(data) != "%PDF-1.4 stub\n" {
+			t.Errorf("%s = %q", name, data)
+		}
+	}
+}
+
+func TestSofficeConverterError(t *testing.T) {
+	stub := writeSofficeStub(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, "broken.docx")
+
+	conv := newSofficeConverter(AppWord, stub)
+	if err := conv.Open(); err != nil {
+		t.Fatal(err)
+	}
+	_, err := conv.Convert(context.Background(), filepath.Join(dir, "broken.docx"), filepath.Join(dir, "broken.pdf"), exportOptions{})
+	if err == nil {
+		t.Error("Convert succeeded, want error")
+	}
+	profile := conv.profile
+	if err := conv.Quit(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(profile); !os.IsNotExist(err) {
+		t.Errorf("profile directory was not removed: %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/exp/slog"
+)
+
+// 他のユーザーが開いているファイルの扱い (-locked)。
+const (
+	// lockedSkip は変換しない。
+	lockedSkip = "skip"
+	// lockedSnapshot はファイルのコピーを変換する。
+	lockedSnapshot = "snapshot"
+	// lockedRetry は他のファイルを変換した後に、閉じられるのを待って変換する。
+	lockedRetry = "retry"
+)
+
+// checkLockedPolicy は -locked の指定が正しいかを確認する。
+func checkLockedPolicy(policy string) error {
+	switch policy {
+	case "", lockedSkip, lockedSnapshot, lockedRetry:
+		return nil
+	}
+	return fmt.Errorf("-locked の指定が正しくありません: %s", policy)
+}
+
+// lockFileNames は Office が name のファイルを開いている間に、同じフォルダに作成する所有者ファイルの名前の候補を返す。
+// Excel と PowerPoint はファイル名の前に ~$ を付ける。
+// Word はファイル名 (拡張子を除く) が7文字の場合は先頭の1文字、8文字以上の場合は先頭の2文字を ~$ で置き換える。
+func lockFileNames(name string, app AppType) []string {
+	names := []string{"~$" + name}
+	if app == AppWord {
+		runes := []rune(name)
+		switch n := len([]rune(strings.TrimSuffix(name, filepath.Ext(name)))); {
+		case n >= 8:
+			names = append(names, "~$"+string(runes[2:]))
+		case n == 7:
+			names = append(names, "~$"+string(runes[1:]))
+		}
+	}
+	return names
+}
+
+// lockOwner は path のファイルを他のユーザーが開いている場合に、locked に true を返す。
+// owner は所有者ファイルに記録されたユーザー名。読み取れない場合は空文字列。
+func lockOwner(path string, app AppType) (owner string, locked bool) {
+	dir, name := filepath.Split(path)
+	for _, lockName := range lockFileNames(name, app) {
+		lockPath := filepath.Join(dir, lockName)
+		info, err := os.Stat(lockPath)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		owner, err := readLockOwner(lockPath)
+		if err != nil {
+			slog.Debug(lockName+" ユーザー名を読み取れませんでした。", "err", err)
+		}
+		return owner, true
+	}
+	return "", false
+}
+
+// readLockOwner は所有者ファイル (~$ で始まるファイル) から、ファイルを開いているユーザー名を読み取る。
+// 所有者ファイルは、先頭1バイトがユーザー名の長さ、続いてユーザー名 (ANSI) で、
+// オフセット 54 に文字数 (2バイト)、続いて UTF-16 のユーザー名が記録されている。
+func readLockOwner(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, 512))
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) >= 56 {
+		n := int(binary.LittleEndian.Uint16(data[54:]))
+		if n > 0 && 56+n*2 <= len(data) {
+			name := make([]uint16, n)
+			for i := range name {
+				name[i] = binary.LittleEndian.Uint16(data[56+i*2:])
+			}
+			if owner := strings.TrimSpace(string(utf16.Decode(name))); owner != "" {
+				return owner, nil
+			}
+		}
+	}
+	// UTF-16 のユーザー名が無い場合は、ASCII の範囲で読み取れるユーザー名を使用する。
+	if len(data) > 0 {
+		n := int(data[0])
+		if n > 0 && 1+n <= len(data) && utf8.Valid(data[1:1+n]) {
+			return strings.TrimSpace(string(data[1 : 1+n])), nil
+		}
+	}
+	return "", nil
+}
+
+// snapshotFile は src を一時フォルダにコピーし、コピーのパスと、一時フォルダを削除する関数を返す。
+// 他のユーザーが開いているファイルを、変換中に変更されないように変換する場合に使用する。
+func snapshotFile(src string) (string, func(), error) {
+	dir, err := os.MkdirTemp("", "office2pdf-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() {
+		if err := os.RemoveAll(dir); err != nil {
+			slog.Warn("一時フォルダを削除できませんでした。", "err", err, "path", dir)
+		}
+	}
+	dst := filepath.Join(dir, filepath.Base(src))
+	if err := copyFile(src, dst); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return dst, cleanup, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+// writeOwnerFile は user が開いていることを示す、Office の所有者ファイルを作成する。
+func writeOwnerFile(t *testing.T, path, user string) {
+	t.Helper()
+	data := make([]byte, 162)
+	data[0] = byte(len(user))
+	copy(data[1:54], user)
+	name := utf16.Encode([]rune(user))
+	binary.LittleEndian.PutUint16(data[54:], uint16(len(name)))
+	for i, c := range name {
+		binary.LittleEndian.PutUint16(data[56+i*2:], c)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLockOwner(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "budget.xlsx", "document.docx", "report7.docx", "memo.docx", "free.xlsx")
+	writeOwnerFile(t, filepath.Join(dir, "~$budget.xlsx"), "山田 太郎")
+	writeOwnerFile(t, filepath.Join(dir, "~$cument.docx"), "suzuki")
+	writeOwnerFile(t, filepath.Join(dir, "~$eport7.docx"), "tanaka")
+	// UTF-16 のユーザー名が無い所有者ファイル
+	if err := os.WriteFile(filepath.Join(dir, "~$memo.docx"), append([]byte{4}, "sato"...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		app    AppType
+		owner  string
+		locked bool
+	}{
+		{"budget.xlsx", AppExcel, "山田 太郎", true},
+		{"document.docx", AppWord, "suzuki", true},
+		{"report7.docx", AppWord, "tanaka", true},
+		{"memo.docx", AppWord, "sato", true},
+		{"free.xlsx", AppExcel, "", false},
+	}
+	for _, tt := range tests {
+		owner, locked := lockOwner(filepath.Join(dir, tt.name), tt.app)
+		if owner != tt.owner || locked != tt.locked {
+			t.Errorf("lockOwner(%q) = %q, %v, want %q, %v", tt.name, owner, locked, tt.owner, tt.locked)
+		}
+	}
+}
+
+func TestRunLocked(t *testing.T) {
+	tests := []struct {
+		policy string
+		status string
+		reason string
+	}{
+		{lockedSkip, statusSkipped, skipLocked},
+		{lockedSnapshot, statusConverted, ""},
+		{lockedRetry, statusSkipped, skipLocked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, "budget.xlsx", "free.xlsx")
+			writeOwnerFile(t, filepath.Join(dir, "~$budget.xlsx"), "yamada")
+
+			backend := newFakeBackend()
+			opts := runOptions{Locked: tt.policy, LockRetries: 2, LockWait: time.Millisecond}
+			result, err := run([]string{dir}, backend.newConverter, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, r := range result.Files {
+				if filepath.Base(r.Source) != "budget.xlsx" {
+					if r.Status != statusConverted || r.Locked {
+						t.Errorf("report = %+v, want converted", r)
+					}
+					continue
+				}
+				if r.Status != tt.status || r.Reason != tt.reason || !r.Locked || r.LockedBy != "yamada" {
+					t.Errorf("report = %+v, want %s (%s) locked by yamada", r, tt.status, tt.reason)
+				}
+			}
+		})
+	}
+}
+
+func TestRunLockedRetry(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "budget.xlsx")
+	lock := filepath.Join(dir, "~$budget.xlsx")
+	writeOwnerFile(t, lock, "yamada")
+
+	// 確認を何回か行った後に、ファイルが閉じられる。
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		os.Remove(lock)
+	}()
+
+	backend := newFakeBackend()
+	opts := runOptions{Locked: lockedRetry, LockRetries: 100, LockWait: 10 * time.Millisecond}
+	result, err := run([]string{dir}, backend.newConverter, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Converted != 1 {
+		t.Errorf("result = %+v, want 1 converted", result)
+	}
+	if r := result.Files[0]; r.Status != statusConverted || !r.Locked {
+		t.Errorf("report = %+v, want converted and locked", r)
+	}
+}
@@ -1,806 +1,771 @@
-package main
-
-import (
-	"bufio"
-	"errors"
-	"flag"
-	"fmt"
-	"os"
-	"path/filepath"
-	"strings"
-	"sync"
-
-	"github.com/go-ole/go-ole"
-	"github.com/go-ole/go-ole/oleutil"
-	"golang.org/x/exp/slog"
-)
-
-var (
-	ignore = flag.String("g", "_", "ExcelでPDF作成対象外とするシート名の先頭文字")
-)
-
-var (
-	ErrOpenFile   = errors.New("ファイルのオープンに失敗しました。")
-	ErrConvertPdf = errors.New("PDFファイルへの変換に失敗しました。")
-)
-
-type ConsoleOutput struct {
-	filetype int
-	log      string
-}
-
-const (
-	MsoTriStateMsoFalse = 0
-	MsoTriStateMsoTrue  = -1
-)
-
-func main() {
-	if len(os.Args) < 2 {
-		slog.Error("引数を指定してください。")
-		slog.Error("Usage: Office2PDF.exe [対象フォルダ]")
-		os.Exit(1)
-	}
-
-	flag.Usage = usage
-	flag.Parse()
-	args := flag.Args()
-	if len(args) == 0 {
-		slog.Error("PDF変換対象フォルダのパスを指定してください。")
-		slog.Error("Usage: Office2PDF.exe [対象フォルダ]")
-		os.Exit(1)
-	}
-
-	targetPath := args[0]
-
-	// 処理対象フォルダから、PDF変換対象ファイルの一覧を取得する。
-	xlsPaths, docPaths, pptPaths, err := getFilePaths(targetPath)
-	if err != nil {
-		slog.Error("ファイル一覧の取得に失敗しました。", err, "path", targetPath)
-		os.Exit(1)
-	}
-	// PDFに変換するファイルが存在しない場合は、処理終了。
-	if len(xlsPaths) == 0 && len(docPaths) == 0 && len(pptPaths) == 0 {
-		slog.Info("PDF変換対象フィルが存在しません。", "path", targetPath)
-		return
-	}
-
-	wg := sync.WaitGroup{}
-	errChan := make(chan error, len(xlsPaths)+len(docPaths)+len(pptPaths))
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		if err := convertExcelFileToPdf(xlsPaths, *ignore); err != nil {
-			errChan <- err
-		}
-	}()
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		if err := convertWordFileToPdf(docPaths); err != nil {
-			errChan <- err
-		}
-	}()
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		if err := convertPptFileToPdf(pptPaths); err != nil {
-			errChan <- err
-		}
-	}()
-
-	wg.Wait()
-	close(errChan)
-
-	flag := true
-	for err := range errChan {
-		if flag {
-			slog.Error("PDF変換でエラーが発生しました。")
-			flag = false
-		}
-		slog.Error("error", err)
-	}
-
-	if !flag {
-		fmt.Print("エラーが発生しました。何かキーを押してください。\n")
-		scanner := bufio.NewScanner(os.Stdin)
-		scanner.Scan()
-		os.Exit(1)
-	}
-}
-
-// PowerPointファイルをPDFに変換する。
-func convertPptFileToPdf(files []string) (rErr error) {
-	if len(files) == 0 {
-		return nil
-	}
-
-	// COMオブジェクトの初期化
-	if err := ole.CoInitializeEx(0, ole.COINIT_MULTITHREADED); err != nil {
-		return err
-	}
-	defer ole.CoUninitialize()
-
-	// PowerPointアプリケーションの作成
-	var pptApp *ole.IDispatch
-	pptApp, rErr = createPowerPointApp()
-	if rErr != nil {
-		return rErr
-	}
-	defer pptApp.Release()
-
-	defer func() {
-		_, err := oleutil.CallMethod(pptApp, "Quit")
-		if err != nil {
-			rErr = errors.Join(rErr, err)
-		}
-		slog.Info("PowerPointを終了しました.")
-	}()
-	slog.Info("PowerPointを起動しました.")
-
-	// // PowerPointウィンドウを表示しないようにする
-	// if _, err := oleutil.PutProperty(pptApp, "Visible", false); err != nil {
-	// 	return err
-	// }
-
-	for _, path := range files {
-		fullpath, err := filepath.Abs(path)
-		if err != nil {
-			return err
-		}
-
-		// 変換元Wordファイルのパスから、PDFファイルのパス（相対パス、絶対パス）を取得する。
-		pdfPath, pdfFullPath, err := getPdfPath(path)
-		if err != nil {
-			return err
-		}
-
-		name := filepath.Base(path)
-		rErr = convertPptxToPdf(pptApp, fullpath, pdfFullPath)
-		if rErr != nil {
-			slog.Error(name+" 変換失敗", "err", rErr, "PDFファイル", pdfPath)
-			return err
-		} else {
-			slog.Info(name+" 変換完了", "PDFファイル", pdfPath)
-		}
-	}
-
-	return nil
-}
-
-// PowerPointファイルをPDFに変換する
-func convertPptxToPdf(powerpoint *ole.IDispatch, pptPath, pdfFilePath string) error {
-	pptname := filepath.Base(pptPath)
-
-	// 　 Dim ppt As New PowerPoint.Application
-	// 　 Dim pres As PowerPoint.Presentation
-	// 　 Dim save_path As String, file_name As String
-	// 　 Dim Target As String
-	// 　 Target = Application.GetOpenFilename("PowerPoint,*.pptx")
-	// 　 If Target = "False" Then Exit Sub
-	// 　 Set pres = ppt.Presentations.Open(Target, WithWindow:=MsoTriState.msoFalse)
-	//
-	// 　 With pres
-	// 　　 save_path = CreateObject("WScript.Shell").SpecialFolders("Desktop")
-	// 　　 file_name = "Test"
-	// 　　 .ExportAsFixedFormat _
-	// 　　　　　 Path:=save_path & "\" & file_name & ".pdf", _
-	// 　　　　　 FixedFormatType:=ppFixedFormatTypePDF
-	// 　 End With
-
-	pres, err := oleutil.GetProperty(powerpoint, "Presentations")
-	if err != nil {
-		return err
-	}
-	defer pres.ToIDispatch().Release()
-
-	// PowerPointドキュメントを開く
-	ppt, err := openPptFile(pres.ToIDispatch(), pptPath)
-	if err != nil {
-		return fmt.Errorf("%w: %s", ErrOpenFile, err.Error())
-	}
-	defer ppt.Release()
-
-	slides, err := oleutil.GetProperty(ppt, "Slides")
-	if err != nil {
-		return err
-	}
-	defer slides.ToIDispatch().Release()
-
-	count := (int)(oleutil.MustGetProperty(slides.ToIDispatch(), "Count").Val)
-	slog.Info(pptname, "スライド数", count)
-
-	ps, err := oleutil.GetProperty(ppt, "PageSetup")
-	if err != nil {
-		return err
-	}
-	defer ps.ToIDispatch().Release()
-
-	sp := (int)(oleutil.MustGetProperty(ps.ToIDispatch(), "FirstSlideNumber").Val)
-	slog.Info(pptname, "スライド開始ページ番号", sp)
-
-	po, err := oleutil.GetProperty(ppt, "PrintOptions")
-	if err != nil {
-		return err
-	}
-	defer po.ToIDispatch().Release()
-
-	r, err := oleutil.GetProperty(po.ToIDispatch(), "Ranges")
-	if err != nil {
-		return err
-	}
-	defer r.ToIDispatch().Release()
-
-	// pr, err := oleutil.CallMethod(r.ToIDispatch(), "Add", 1, count)
-	pr, err := oleutil.CallMethod(r.ToIDispatch(), "Add", sp, count+(sp-1))
-	if err != nil {
-		return err
-	}
-	// defer pr.ToIDispatch().Release()
-
-	// PDFに変換する
-	// ExportAsFixedFormat (
-	// 	Path,
-	// 	FixedFormatType, : ppFixedFormatTypePDF(2)
-	// 	Intent, : ppFixedFormatIntentPrint(2)
-	// 	FrameSlides, : msoFalse(0)
-	// 	HandoutOrder, : ppPrintHandoutVerticalFirst(1)
-	// 	OutputType, : ppPrintOutputSlides(1)
-	// 	PrintHiddenSlides, : msoFalse(0)
-	// 	PrintRange,
-	// 	RangeType, : ppPrintAll(1)
-	// 	SlideShowName, : ""
-	// 	IncludeDocProperties, : false
-	// 	KeepIRMSettings, : false
-	// 	DocStructureTags, : false
-	// 	BitmapMissingFonts, : false
-	// 	UseISO19005_1, : false
-	// 	ExternalExporter : nil
-	//)
-	// _, err = oleutil.CallMethod(ppt.ToIDispatch(), "ExportAsFixedFormat", pdfFilePath, 2, 2, 0, 1, 1, 0, pr, 1, "", false, false, false, false, false, nil)
-	_, err = oleutil.CallMethod(ppt, "ExportAsFixedFormat", pdfFilePath, 2, 2, 0, 1, 1, 0, pr, 1, "", false, false, false, false, false)
-	//   ppFixedFormatTypePDF, ppFixedFormatIntentScreen, msoCTrue, ppPrintHandoutHorizontalFirst, ppPrintOutputBuildSlides, msoFalse, , , , False, False, False, False, False
-	if err != nil {
-		return fmt.Errorf("%w: %s", ErrConvertPdf, err.Error())
-	}
-
-	_, err = oleutil.PutProperty(ppt, "Saved", true)
-	if err != nil {
-		return err
-	}
-	_, err = oleutil.CallMethod(ppt, "Close")
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-// PowerPointのファイルをオープンする。
-func openPptFile(pres *ole.IDispatch, path string) (*ole.IDispatch, error) {
-	// Open (FileName、 ReadOnly、 Untitled、 WithWindow)
-	//  FileName	必須	文字列型 (String)	開くファイルの名前を指定します。
-	//  ReadOnly	省略可能	MsoTriState	読み取り/書き込み可能な状態でファイルを開くか、または読み取り専用で開くかを指定します。
-	//		msoFalse	既定値です。 読み取り/書き込み可能な状態でファイルを開きます。
-	//		msoTrue	読み取り専用でファイルを開きます。
-	//  Untitled	省略可能	MsoTriState	ファイルにタイトルを設定するかどうかを指定します。
-	//		msoFalse	既定値です。 ファイル名が自動的に、開かれたプレゼンテーションのタイトルとなります。
-	//		msoTrue	タイトルなしにファイルを開きます。 これは、ファイルのコピーを作成することと同じです。
-	//  WithWindow	省略可能	MsoTriState	ファイルを表示するかどうかを指定します。
-	//		msoFalse	開かれたプレゼンテーションを非表示にします。
-	//		msoTrue	既定値です。 ファイルを表示可能なウィンドウで開きます。
-	ppt, err := oleutil.CallMethod(pres, "Open", path, MsoTriStateMsoTrue, MsoTriStateMsoFalse, MsoTriStateMsoFalse)
-	// ppt, err := oleutil.CallMethod(pres, "Open", path)
-	if err != nil {
-		return nil, fmt.Errorf("%w: %s", ErrOpenFile, err.Error())
-	}
-	return ppt.ToIDispatch(), nil
-}
-
-func createPrintRange(pptname string, ppt *ole.VARIANT) (*ole.VARIANT, error) {
-	ps, err := oleutil.GetProperty(ppt.ToIDispatch(), "PageSetup")
-	if err != nil {
-		return nil, err
-	}
-	defer ps.ToIDispatch().Release()
-
-	slides, err := oleutil.GetProperty(ppt.ToIDispatch(), "Slides")
-	if err != nil {
-		return nil, err
-	}
-	defer slides.ToIDispatch().Release()
-
-	count := (int)(oleutil.MustGetProperty(slides.ToIDispatch(), "Count").Val)
-	slog.Info(pptname, "スライド数", count)
-
-	sp := (int)(oleutil.MustGetProperty(ps.ToIDispatch(), "FirstSlideNumber").Val)
-	slog.Info(pptname, "スライド開始ページ番号", sp)
-
-	po, err := oleutil.GetProperty(ppt.ToIDispatch(), "PrintOptions")
-	if err != nil {
-		return nil, err
-	}
-
-	defer po.ToIDispatch().Release()
-	r, err := oleutil.GetProperty(po.ToIDispatch(), "Ranges")
-	if err != nil {
-		return nil, err
-	}
-
-	defer r.ToIDispatch().Release()
-	// pr, err := oleutil.CallMethod(r.ToIDispatch(), "Add", 1, count)
-	pr, err := oleutil.CallMethod(r.ToIDispatch(), "Add", sp, count+(sp-1))
-	if err != nil {
-		return nil, err
-	}
-	// defer pr.ToIDispatch().Release()
-
-	return pr, nil
-}
-
-// WordファイルをPDFに変換する。
-func convertWordFileToPdf(files []string) (rErr error) {
-	if len(files) == 0 {
-		return nil
-	}
-
-	// COMオブジェクトの初期化
-	if err := ole.CoInitializeEx(0, ole.COINIT_MULTITHREADED); err != nil {
-		return err
-	}
-	defer ole.CoUninitialize()
-
-	// Wordアプリケーションの作成
-	var wordApp *ole.IDispatch
-	wordApp, rErr = createWordApp()
-	if rErr != nil {
-		return rErr
-	}
-	defer wordApp.Release()
-
-	defer func() {
-		_, err := oleutil.CallMethod(wordApp, "Quit")
-		if err != nil {
-			rErr = errors.Join(rErr, err)
-		}
-		slog.Info("Wordを終了しました.")
-	}()
-	slog.Info("Wordを起動しました.")
-
-	// Wordウィンドウを表示しないようにする
-	if _, err := oleutil.PutProperty(wordApp, "Visible", false); err != nil {
-		return err
-	}
-
-	for _, path := range files {
-		fullpath, err := filepath.Abs(path)
-		if err != nil {
-			return err
-		}
-
-		// 変換元Wordファイルのパスから、PDFファイルのパス（相対パス、絶対パス）を取得する。
-		pdfPath, pdfFullPath, err := getPdfPath(path)
-		if err != nil {
-			return err
-		}
-
-		name := filepath.Base(path)
-		rErr = convertDocxToPdf(wordApp, fullpath, pdfFullPath)
-		if rErr != nil {
-			slog.Error(name+" 変換失敗", "err", rErr, "PDFファイル", pdfPath)
-			return err
-		} else {
-			slog.Info(name+" 変換完了", "PDFファイル", pdfPath)
-		}
-	}
-
-	return nil
-}
-
-// WordファイルをPDFに変換する
-func convertDocxToPdf(word *ole.IDispatch, dcPath, pdfFilePath string) error {
-	documents, err := oleutil.GetProperty(word, "documents")
-	if err != nil {
-		return err
-	}
-	defer documents.ToIDispatch().Release()
-
-	// Wordドキュメントを開く
-	doc, err := oleutil.CallMethod(documents.ToIDispatch(), "Open", dcPath)
-	if err != nil {
-		return err
-	}
-	defer doc.ToIDispatch().Release()
-
-	// PDFに変換する
-	_, err = oleutil.CallMethod(doc.ToIDispatch(), "ExportAsFixedFormat", pdfFilePath, 17)
-	if err != nil {
-		return err
-	}
-
-	_, err = oleutil.CallMethod(doc.ToIDispatch(), "Close", false)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-// ExcelファイルをPDFに変換する。
-func convertExcelFileToPdf(files []string, ig string) (rErr error) {
-	if len(files) == 0 {
-		return nil
-	}
-
-	// COMオブジェクトの初期化
-	if err := ole.CoInitializeEx(0, ole.COINIT_MULTITHREADED); err != nil {
-		return err
-	}
-	defer ole.CoUninitialize()
-
-	// Excelアプリケーションの生成
-	var excelApp *ole.IDispatch
-	excelApp, rErr = createExcelApp()
-	if rErr != nil {
-		return rErr
-	}
-	defer excelApp.Release()
-	defer func() {
-		_, err := oleutil.CallMethod(excelApp, "Quit")
-		if err != nil {
-			rErr = errors.Join(rErr, err)
-		}
-		slog.Info("Excelを終了しました.")
-	}()
-	slog.Info("Excelを起動しました.")
-
-	for _, path := range files {
-		fullpath, err := filepath.Abs(path)
-		if err != nil {
-			return err
-		}
-
-		// 変換元Excelファイルのパスから、PDFファイルのパス（相対パス、絶対パス）を取得する。
-		pdfPath, pdfFullPath, err := getPdfPath(path)
-		if err != nil {
-			return err
-		}
-
-		name := filepath.Base(path)
-		rErr = convertXlsxToPdf(excelApp, fullpath, pdfFullPath, ig)
-		if rErr != nil {
-			slog.Error(name+" 変換完了", err, "PDFファイル", pdfPath)
-			return err
-		} else {
-			slog.Info(name+" 変換完了", "PDFファイル", pdfPath)
-		}
-	}
-
-	return nil
-}
-
-// ExcelファイルをPDFに変換する
-func convertXlsxToPdf(excel *ole.IDispatch, xlPath, pdfFilePath, ig string) error {
-	xlname := filepath.Base(xlPath)
-	workbooks, err := oleutil.GetProperty(excel, "Workbooks")
-	if err != nil {
-		return err
-	}
-	defer workbooks.ToIDispatch().Release()
-	workbook, err := oleutil.CallMethod(workbooks.ToIDispatch(), "Open", xlPath)
-	if err != nil {
-		return err
-	}
-	defer workbook.ToIDispatch().Release()
-
-	if ig == "" {
-		// PDF形式で保存
-		_, err = oleutil.CallMethod(workbook.ToIDispatch(), "ExportAsFixedFormat", 0, pdfFilePath, 0, false, false)
-		if err != nil {
-			return err
-		}
-	} else {
-		worksheets, err := oleutil.GetProperty(workbook.ToIDispatch(), "Worksheets")
-		if err != nil {
-			return err
-		}
-		defer worksheets.ToIDispatch().Release()
-
-		sheetCount := (int)(oleutil.MustGetProperty(worksheets.ToIDispatch(), "Count").Val)
-		slog.Info(xlname, "シート数", sheetCount)
-
-		var worksheet *ole.IDispatch
-		for i := 1; i < sheetCount+1; i++ {
-			worksheet = oleutil.MustGetProperty(workbook.ToIDispatch(), "Worksheets", i).ToIDispatch()
-			defer worksheet.Release()
-			name := oleutil.MustGetProperty(worksheet, "Name")
-			if strings.HasPrefix(name.ToString(), ig) {
-				slog.Info(xlname+" シート名によりスキップ", "シート名", name.ToString())
-				continue
-			} else {
-				_, err := oleutil.CallMethod(worksheet, "Select", false)
-				if err != nil {
-					return err
-				}
-				// defer selected.ToIDispatch().Release()
-			}
-		}
-
-		activeSheet, err := oleutil.GetProperty(workbook.ToIDispatch(), "ActiveSheet")
-		if err != nil {
-			return err
-		}
-		defer activeSheet.ToIDispatch().Release()
-
-		_, err = oleutil.CallMethod(activeSheet.ToIDispatch(), "ExportAsFixedFormat", 0, pdfFilePath, 0, false, false)
-		// _, err = oleutil.CallMethod(workbook.ToIDispatch(), "ExportAsFixedFormat", 0, pdfFilePath, 0, false, false)
-		if err != nil {
-			return err
-		}
-	}
-
-	_, err = oleutil.PutProperty(workbook.ToIDispatch(), "Saved", true)
-	if err != nil {
-		return err
-	}
-	_, err = oleutil.CallMethod(workbook.ToIDispatch(), "Close", false)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func convertFileToPdf() filepath.WalkFunc {
-	// var eFlag bool
-	// var wFlag, pFlag bool
-	// var excelApp *ole.IDispatch
-	// var wordApp, ppointApp *ole.IDispatch
-
-	return func(path string, info os.FileInfo, err error) (rErr error) {
-		if err != nil {
-			return err
-		}
-		if info.IsDir() || strings.HasPrefix(filepath.Base(path), "~") {
-			return nil
-		}
-		fullpath, err := filepath.Abs(path)
-		if err != nil {
-			return err
-		}
-		ext := filepath.Ext(fullpath)
-		if ext != ".docx" && ext != ".xlsx" && ext != ".xls" && ext != ".pptx" {
-			return nil
-		}
-		// pdfPath := getPathWithoutExt(path) + ".pdf"
-		// pdfFullPath, err := filepath.Abs(pdfPath)
-		if err != nil {
-			return err
-		}
-
-		switch ext {
-		// case ".docx":
-		// 	if !wFlag {
-		// 		wFlag = true
-		// 		// Wordアプリケーションの作成
-		// 		wordApp, err2 = createWordApp()
-		// 		if err2 != nil {
-		// 			return err2
-		// 		}
-		// 		defer wordApp.Release()
-		// 	}
-		// 	err = convertDocxToPdf(wordApp, fullpath, pdfPath)
-		case ".xlsx", ".xls":
-			// if !eFlag {
-			// 	eFlag = true
-			// 	// Excelアプリケーションの生成
-			// 	excelApp, rErr = createExcelApp()
-			// 	if rErr != nil {
-			// 		return rErr
-			// 	}
-			// 	defer excelApp.Release()
-			// 	defer func() {
-			// 		_, err := oleutil.CallMethod(excelApp, "Quit")
-			// 		if err != nil {
-			// 			rErr = errors.Join(rErr, err)
-			// 		}
-			// 		slog.Info("Excel has exited.")
-			// 	}()
-			// 	slog.Info("Excel launched.")
-			// }
-			// rErr = convertXlsxToPdf(excelApp, fullpath, pdfFullPath)
-			// if rErr != nil {
-			// 	slog.Error("Failed to convert.", err, path, pdfPath)
-			// } else {
-			// 	slog.Info("Converted.", "excel-path", path, "pdf-path", pdfPath)
-			// }
-		case ".pptx":
-			// if !pFlag {
-			// 	pFlag = true
-			// 	// PowerPointオブジェクトの生成
-			// 	ppointApp, err2 = createPowerPointApp()
-			// 	if err2 != nil {
-			// 		return err2
-			// 	}
-			// 	defer ppointApp.Release()
-			// }
-			// err = convertPptxToPdf(ppointApp, fullpath, pdfPath)
-		}
-		return nil
-	}
-}
-
-// func convertDocxToPdf(docxPath string, pdfPath string) error {
-// doc, err := document.Open(docxPath)
-// if err != nil {
-// 	return err
-// }
-// defer doc.Close()
-// pdf, err := os.Create(pdfPath)
-// if err != nil {
-// 	return err
-// }
-// defer pdf.Close()
-// err = doc.Save(pdf, document.SaveOptionPDFPageWidth(8.5), document.SaveOptionPDFPageHeight(11))
-// if err != nil {
-// 	return err
-// }
-// 	return nil
-// }
-
-// func convertPptxToPdf(pptxPath string, pdfPath string) error {
-// 	// prs, err := presentation.Open(pptxPath)
-// 	// if err != nil {
-// 	// 	return err
-// 	// }
-// 	// defer prs.Close()
-// 	// pdf, err := os.Create(pdfPath)
-// 	// if err != nil {
-// 	// 	return err
-// 	// }
-// 	// defer pdf.Close()
-// 	// err = prs.SaveToPDF(pdf)
-// 	// if err != nil {
-// 	// 	return err
-// 	// }
-// 	return nil
-// }
-
-// func convertToPDF(filepath string) error {
-// 	// Wordオブジェクトを取得する
-// 	word, err := unknown.QueryInterface(ole.IID_IDispatch)
-// 	if err != nil {
-// 		return err
-// 	}
-// 	defer word.Release()
-// 	// Wordウィンドウを表示しないようにする
-// 	_, err = oleutil.PutProperty(word, "Visible", false)
-// 	if err != nil {
-// 		return err
-// 	}
-// 	// Wordドキュメントを開く
-// 	doc, err := oleutil.CallMethod(word, "Documents", "Open", filepath)
-// 	if err != nil {
-// 		return err
-// 	}
-// 	defer doc.Release()
-// 	// PDFに変換する
-// 	_, err = oleutil.CallMethod(doc, "ExportAsFixedFormat", filepath+".pdf", 17)
-// 	if err != nil {
-// 		return err
-// 	}
-// 	return nil
-// }
-
-// func convertToPDF(filePath string) error {
-// 	// PowerPointオブジェクトをPowerPoint.Applicationオブジェクトにキャスト
-// 	powerPoint, err := unknown.QueryInterface(ole.IID_IDispatch)
-// 	if err != nil {
-// 		return fmt.Errorf("PowerPoint.Applicationオブジェクトの生成に失敗しました: %v", err)
-// 	}
-// 	defer powerPoint.Release()
-// 	// プレゼンテーションの読み込み
-// 	presentation, err := oleutil.CallMethod(powerPoint, "Presentations", filePath)
-// 	if err != nil {
-// 		return fmt.Errorf("プレゼンテーションの読み込みに失敗しました: %v", err)
-// 	}
-// 	// PDFファイルへの変換
-// 	pdfFilePath := filePath + ".pdf"
-// 	_, err = oleutil.CallMethod(presentation.ToIDispatch(), "SaveAs", pdfFilePath, 32)
-// 	if err != nil {
-// 		return fmt.Errorf("PDFへの変換に失敗しました: %v", err)
-// 	}
-// 	return nil
-// }
-
-// pathから拡張子を除いたファイル名を返す
-func getFileNameWithoutExt(path string) string {
-	return filepath.Base(path[:len(path)-len(filepath.Ext(path))])
-}
-
-// patshから拡張子を除いたパスを返す
-func getPathWithoutExt(path string) string {
-	return path[:len(path)-len(filepath.Ext(path))]
-}
-
-// Wordアプリケーションの作成
-func createWordApp() (*ole.IDispatch, error) {
-	if unknown, err := oleutil.CreateObject("Word.Application"); err != nil {
-		return nil, err
-	} else {
-		wordApp, err := unknown.QueryInterface(ole.IID_IDispatch)
-		if err != nil {
-			return nil, err
-		}
-		return wordApp, nil
-	}
-}
-
-// Excelアプリケーションの作成
-func createExcelApp() (*ole.IDispatch, error) {
-	if unknown, err := oleutil.CreateObject("Excel.Application"); err != nil {
-		return nil, err
-	} else {
-		excelApp, err := unknown.QueryInterface(ole.IID_IDispatch)
-		if err != nil {
-			return nil, err
-		}
-		return excelApp, nil
-	}
-}
-
-// PowerPointオブジェクトの生成
-func createPowerPointApp() (*ole.IDispatch, error) {
-	if unknown, err := oleutil.CreateObject("PowerPoint.Application"); err != nil {
-		return nil, err
-	} else {
-		ppointApp, err := unknown.QueryInterface(ole.IID_IDispatch)
-		if err != nil {
-			return nil, err
-		}
-		return ppointApp, nil
-	}
-}
-
-// folderPath で指定されたフォルダから、サブフォルダも含めたPDF変換対象ファイルの一覧を取得する。
-// PDF変換対象ファイルの一覧は、Excel、Word、PowerPointに分けて、配列で返す。
-func getFilePaths(folderPath string) ([]string, []string, []string, error) {
-	var xslPaths, docPaths, pptPaths []string
-	err := filepath.Walk(folderPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		// フォルダと~で始まるファイルはスキップ
-		if !info.IsDir() || strings.HasPrefix(filepath.Base(path), "~") {
-			switch filepath.Ext(info.Name()) {
-			case ".xlsx", ".xls":
-				xslPaths = append(xslPaths, path)
-			case ".docx", "doc":
-				docPaths = append(docPaths, path)
-			case ".pptx", ".ppt":
-				pptPaths = append(pptPaths, path)
-			}
-		}
-		return nil
-	})
-	if err != nil {
-		return nil, nil, nil, err
-	}
-	return xslPaths, docPaths, pptPaths, nil
-}
-
-func usage() {
-	slog.Info("usage: PDFConverterGO [flags] path")
-	flag.PrintDefaults()
-}
-
-func getPdfPath(path string) (string, string, error) {
-	pdfPath := getPathWithoutExt(path) + ".pdf"
-	pdfFullPath, err := filepath.Abs(pdfPath)
-	if err != nil {
-		return "", "", err
-	}
-	return pdfPath, pdfFullPath, nil
-}
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+var (
+	ignore       = flag.String("g", "_", "ExcelでPDF作成対象外とするシート名の先頭文字 (-backend libreoffice では使用せず、全てのシートを出力する)")
+	backend      = flag.String("backend", "office", "PDF変換に使用するアプリケーション (office: Microsoft Office, libreoffice: LibreOffice)")
+	soffice      = flag.String("soffice", "soffice", "-backend libreoffice で使用する soffice コマンドのパス")
+	failFast     = flag.Bool("fail-fast", false, "変換に失敗したファイルがあった場合に、残りのファイルを変換せずに終了する")
+	jobs         = flag.Int("j", 1, "アプリケーションの種類ごとに同時に起動するインスタンスの数")
+	jobsXls      = flag.Int("j-excel", 0, "同時に起動するExcelの数 (0 の場合は -j の値)")
+	jobsDoc      = flag.Int("j-word", 0, "同時に起動するWordの数 (0 の場合は -j の値)")
+	jobsPpt      = flag.Int("j-ppt", 0, "同時に起動するPowerPointの数 (0 の場合は -j の値)")
+	timeout      = flag.Duration("timeout", 0, "1ファイルあたりの変換の制限時間 (例: 2m)。超過した場合はアプリケーションを再起動する。0 の場合は無制限")
+	incremental  = flag.Bool("incremental", false, "PDFが変換元ファイルより新しい場合は変換しない")
+	outDir       = flag.String("o", "", "PDFの出力先フォルダ。対象フォルダのフォルダ構成を再現して出力する。省略時は変換元ファイルと同じフォルダ")
+	nameTmpl     = flag.String("name", defaultNameTemplate, "PDFファイル名のテンプレート。{name}: ファイル名, {ext}: 拡張子, {parent}: フォルダ名, {date}: 更新日, {sheet}: シート名 (-split-sheets の場合のみ) (例: {date}_{name}.pdf, {parent}/{name}.pdf)")
+	maxDepth     = flag.Int("max-depth", 0, "辿るフォルダの深さの上限。1 の場合は対象フォルダ直下のみ。0 の場合は無制限")
+	skipHidden   = flag.Bool("skip-hidden", true, "隠しフォルダ (. で始まるフォルダ、隠し属性・システム属性のフォルダ) を辿らない")
+	hiddenSheets = flag.Bool("skip-hidden-sheets", true, "Excelの非表示のシートをPDFに出力しない")
+	splitSheets  = flag.Bool("split-sheets", false, "Excelのシートごとに別のPDFに出力する。-name に {sheet} が無い場合は {name}__{sheet}.pdf とする")
+	symlinks     = flag.String("symlinks", symlinksIgnore, "シンボリックリンクの扱い (ignore: 無視する, follow: リンク先を辿る)")
+	includes     stringsFlag
+	excludes     stringsFlag
+	sheetInclude stringsFlag
+	sheetExclude stringsFlag
+	sheetNames   stringsFlag
+	collision    = flag.String("collision", collisionExt, "出力するPDFファイル名が重複した場合の扱い (ext: 拡張子を付ける, number: 連番を付ける, fail: エラーとする)")
+	reportPath   = flag.String("report", "", "ファイルごとの変換結果を出力するレポートファイル (.json または .csv)。watch では使用できない")
+	dryRun       = flag.Bool("dry-run", false, "Officeを起動せずに、変換対象ファイルと出力先の一覧を表示する")
+	dryRunFormat = flag.String("dry-run-format", "text", "-dry-run の出力形式 (text または json)")
+	manifestPath = flag.String("manifest", "", "-incremental で更新日時の代わりに内容のハッシュ値で判定し、ハッシュ値をこのファイルに記録する")
+	formatsPath  = flag.String("formats", "", "PDF変換対象とする形式を追加・変更する設定ファイル (JSON)。拡張子ごとに、使用するアプリケーションとPDF出力の指定を記述する")
+	lockedPolicy = flag.String("locked", lockedSnapshot, "他のユーザーが開いているファイルの扱い (skip: 変換しない, snapshot: コピーを変換する, retry: 閉じられるのを待って変換する)")
+	lockRetries  = flag.Int("lock-retries", 3, "-locked retry で、ファイルが閉じられたかを確認する回数")
+	lockWait     = flag.Duration("lock-wait", 30*time.Second, "-locked retry で、ファイルが閉じられたかを確認する間隔")
+	pollInterval = flag.Duration("interval", 2*time.Second, "watch でフォルダを確認する間隔")
+	debounce     = flag.Duration("debounce", 3*time.Second, "watch でファイルの変更が落ち着いたと判断するまでの時間。保存が続いている間は変換しない")
+	passwordFile = flag.String("passwords", "", "パスワードで保護されたファイルを開く時に、順に試すパスワードを1行に1つ記述したファイル")
+	filesFrom    = flag.String("files-from", "", "PDF変換対象のファイルまたはフォルダを1行に1つ記述したファイル。- の場合は標準入力から読み込む")
+)
+
+func init() {
+	flag.Var(&includes, "include", "変換対象とするファイルのパターン (例: *.xlsx, reports/**)。複数指定できる")
+	flag.Var(&excludes, "exclude", "変換対象外とするファイルやフォルダのパターン (例: old/, ~*.docx)。複数指定できる")
+	flag.Var(&sheetInclude, "sheet-include", "ExcelでPDF作成対象とするシート名の正規表現。複数指定できる")
+	flag.Var(&sheetExclude, "sheet-exclude", "ExcelでPDF作成対象外とするシート名の正規表現。複数指定できる")
+	flag.Var(&sheetNames, "sheet-exclude-name", "ExcelでPDF作成対象外とするシート名 (完全一致)。複数指定できる")
+}
+
+// isFlagSet はコマンドラインで name のフラグが指定された場合に true を返す。
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// stringsFlag は複数回指定できる文字列のフラグ。
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+var (
+	ErrOpenFile   = errors.New("ファイルのオープンに失敗しました。")
+	ErrConvertPdf = errors.New("PDFファイルへの変換に失敗しました。")
+	ErrTimeout    = errors.New("PDFファイルへの変換がタイムアウトしました。")
+	ErrPassword   = errors.New("登録されたパスワードでファイルを開けませんでした。")
+)
+
+type ConsoleOutput struct {
+	filetype int
+	log      string
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		slog.Error("引数を指定してください。")
+		slog.Error("Usage: Office2PDF.exe [対象フォルダまたはファイル...]")
+		os.Exit(1)
+	}
+
+	flag.Usage = usage
+	// office2pdf watch [flags] 対象フォルダ で、フォルダを監視して変換する。
+	watchMode := os.Args[1] == "watch"
+	if watchMode {
+		flag.CommandLine.Parse(os.Args[2:])
+	} else {
+		flag.Parse()
+	}
+	targets := flag.Args()
+	if *filesFrom != "" {
+		list, err := readFileListFrom(*filesFrom)
+		if err != nil {
+			slog.Error("-files-from の読み込みに失敗しました。", "err", err, "path", *filesFrom)
+			os.Exit(1)
+		}
+		targets = append(targets, list...)
+	}
+	if len(targets) == 0 {
+		slog.Error("PDF変換対象フォルダまたはファイルのパスを指定してください。")
+		slog.Error("Usage: Office2PDF.exe [対象フォルダまたはファイル...]")
+		os.Exit(1)
+	}
+
+	if *formatsPath != "" {
+		if err := formats.load(*formatsPath); err != nil {
+			slog.Error("-formats の読み込みに失敗しました。", "err", err, "path", *formatsPath)
+			os.Exit(1)
+		}
+	}
+
+	var passwords []string
+	if *passwordFile != "" {
+		p, err := loadPasswords(*passwordFile)
+		if err != nil {
+			slog.Error("-passwords の読み込みに失敗しました。", "err", err, "path", *passwordFile)
+			os.Exit(1)
+		}
+		passwords = p
+		// soffice はコマンドラインでパスワードを指定できない。
+		if *backend == "libreoffice" && len(passwords) > 0 {
+			slog.Warn("-backend libreoffice ではパスワードを使用できないため、パスワードで保護されたファイルはスキップします。")
+			passwords = nil
+		}
+	}
+
+	sheets, err := newSheetSelector(*ignore, sheetInclude, sheetExclude, sheetNames)
+	if err != nil {
+		slog.Error("シート名の正規表現が正しくありません。", "err", err)
+		os.Exit(1)
+	}
+	sheets.SkipHidden = *hiddenSheets
+	// -g は既定値があるため、明示的に指定された場合のみ警告する。
+	if *backend == "libreoffice" && ((isFlagSet("g") && *ignore != "") || len(sheetInclude) > 0 || len(sheetExclude) > 0 || len(sheetNames) > 0) {
+		slog.Warn("-backend libreoffice では、シートを選択できません。全てのシートを出力します。")
+	}
+	// LibreOffice は全てのシートを出力するため、計画のプレビューでもシートを除外しない。
+	planSheets := sheets
+	if *backend == "libreoffice" {
+		planSheets = nil
+	}
+	split := *splitSheets
+	if *backend == "libreoffice" && split {
+		slog.Warn("-backend libreoffice では、シートごとに出力できません。ブックごとに1つのPDFに出力します。")
+		split = false
+	}
+
+	newConverter, err := newConverterFactory(*backend, sheets, passwords)
+	if err != nil {
+		slog.Error(err.Error())
+		os.Exit(1)
+	}
+
+	tmpl, err := parseNameTemplate(*nameTmpl, split)
+	if err != nil {
+		slog.Error("-name の指定が正しくありません。", "err", err)
+		os.Exit(1)
+	}
+
+	opts := runOptions{
+		FailFast: *failFast,
+		Jobs: map[AppType]int{
+			AppExcel:      *jobsXls,
+			AppWord:       *jobsDoc,
+			AppPowerPoint: *jobsPpt,
+		},
+		DefaultJobs:  *jobs,
+		Timeout:      *timeout,
+		Incremental:  *incremental,
+		OutDir:       *outDir,
+		NameTemplate: tmpl,
+		SplitSheets:  split,
+		Collision:    *collision,
+		Include:      includes,
+		Exclude:      excludes,
+		MaxDepth:     *maxDepth,
+		SkipHidden:   *skipHidden,
+		Symlinks:     *symlinks,
+		Passwords:    passwords,
+		Locked:       *lockedPolicy,
+		LockRetries:  *lockRetries,
+		LockWait:     *lockWait,
+		Preview:      *reportPath != "",
+		Sheets:       planSheets,
+	}
+	if *incremental && *manifestPath != "" {
+		m, err := loadManifest(*manifestPath)
+		if err != nil {
+			slog.Error("マニフェストファイルの読み込みに失敗しました。", "err", err, "path", *manifestPath)
+			os.Exit(1)
+		}
+		opts.Manifest = m
+	}
+	// PowerPointはCOMで複数のインスタンスを起動できないため、1つで変換する。
+	if *backend == "office" && opts.jobs(AppPowerPoint) > 1 {
+		slog.Warn("PowerPointは複数起動できないため、同時実行数を 1 にします。")
+		opts.Jobs[AppPowerPoint] = 1
+	}
+
+	if err := checkSymlinkPolicy(*symlinks); err != nil {
+		slog.Error(err.Error())
+		os.Exit(1)
+	}
+	if err := checkCollisionPolicy(*collision); err != nil {
+		slog.Error(err.Error())
+		os.Exit(1)
+	}
+	if err := checkLockedPolicy(*lockedPolicy); err != nil {
+		slog.Error(err.Error())
+		os.Exit(1)
+	}
+	if *dryRun {
+		if err := checkPlanFormat(*dryRunFormat); err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+	}
+	if *reportPath != "" {
+		if err := checkReportPath(*reportPath); err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+	}
+
+	if watchMode {
+		if *dryRun {
+			slog.Error("watch では -dry-run を使用できません。")
+			os.Exit(1)
+		}
+		if *reportPath != "" {
+			slog.Error("watch では -report を使用できません。")
+			os.Exit(1)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		err := watch(ctx, targets, newConverter, opts, watchOptions{Interval: *pollInterval, Debounce: *debounce})
+		if err != nil {
+			slog.Error("フォルダの監視に失敗しました。", "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *dryRun {
+		entries, err := buildPlan(targets, opts)
+		if err != nil {
+			slog.Error("PDF変換対象ファイルの取得に失敗しました。", "err", err)
+			os.Exit(1)
+		}
+		previewEntries(entries, planSheets)
+		if err := printPlan(os.Stdout, entries, *dryRunFormat); err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	result, err := run(targets, newConverter, opts)
+	if err != nil {
+		slog.Error("PDF変換対象ファイルの取得に失敗しました。", "err", err)
+		os.Exit(1)
+	}
+
+	if *reportPath != "" {
+		if err := writeReport(*reportPath, result.Files); err != nil {
+			slog.Error("レポートの出力に失敗しました。", "err", err, "path", *reportPath)
+			result.Errs = append(result.Errs, err)
+		}
+	}
+
+	slog.Info("PDF変換が終了しました。", "対象", result.Total, "成功", result.Converted, "スキップ", result.Skipped, "失敗", result.Failed())
+	if len(result.Errs) > 0 {
+		slog.Error("PDF変換でエラーが発生しました。")
+		for _, err := range result.Errs {
+			slog.Error("error", "err", err)
+		}
+
+		fmt.Print("エラーが発生しました。何かキーを押してください。\n")
+		scanner := bufio.NewScanner(os.Stdin)
+		scanner.Scan()
+		os.Exit(1)
+	}
+}
+
+// backend で指定されたアプリケーションの Converter を生成する関数を返す。
+// sheets はExcelでPDFに出力するシート、passwords はパスワードで保護されたファイルを開く時に試すパスワード。
+func newConverterFactory(backend string, sheets *sheetSelector, passwords []string) (converterFactory, error) {
+	switch backend {
+	case "office":
+		return func(app AppType) Converter {
+			return newOleConverter(app, sheets, passwords)
+		}, nil
+	case "libreoffice":
+		return func(app AppType) Converter {
+			return newSofficeConverter(app, *soffice)
+		}, nil
+	}
+	return nil, fmt.Errorf("-backend の指定が正しくありません: %s", backend)
+}
+
+// runOptions はPDF変換の実行方法の指定。
+type runOptions struct {
+	// FailFast が true の場合、変換に失敗したファイルがあった時点で、
+	// そのアプリケーションの残りのファイルを変換しない。
+	FailFast bool
+	// Jobs はアプリケーションの種類ごとに同時に起動するインスタンスの数。
+	// 指定の無い種類は DefaultJobs を使用する。
+	Jobs        map[AppType]int
+	DefaultJobs int
+	// Timeout は1ファイルあたりの変換の制限時間。0 の場合は無制限。
+	// 制限時間を超えたアプリケーションは強制終了し、新しいインスタンスで残りのファイルを変換する。
+	Timeout time.Duration
+	// Incremental が true の場合、PDFが最新のファイルは変換しない。
+	// Manifest が nil の場合は更新日時で、それ以外はマニフェストのハッシュ値で判定する。
+	Incremental bool
+	Manifest    *manifest
+	// OutDir はPDFの出力先フォルダ。空の場合は、変換元ファイルと同じフォルダに出力する。
+	OutDir string
+	// NameTemplate はPDFファイル名のテンプレート。nil の場合は変換元ファイルと同じ名前にする。
+	NameTemplate *nameTemplate
+	// SplitSheets が true の場合、Excelのシートごとに別のPDFに出力する。
+	SplitSheets bool
+	// Collision は出力するPDFファイル名が重複した場合の扱い。
+	Collision string
+	// Include、Exclude は変換対象とするファイル、変換対象外とするファイルのパターン。
+	// 対象フォルダの .office2pdfignore と合わせて適用する。
+	Include []string
+	Exclude []string
+	// MaxDepth、SkipHidden、Symlinks はフォルダの辿り方。walkOptions を参照。
+	MaxDepth   int
+	SkipHidden bool
+	Symlinks   string
+	// Passwords はパスワードで保護されたファイルを開く時に試すパスワード。
+	// 空の場合、パスワードで保護されたファイルは変換しない。
+	Passwords []string
+	// Locked は他のユーザーが開いているファイルの扱い。空の場合は lockedSnapshot。
+	Locked string
+	// LockRetries と LockWait は、-locked retry でファイルが閉じられたかを確認する回数と間隔。
+	LockRetries int
+	LockWait    time.Duration
+	// Preview が true の場合、Officeを起動せずに読み取ったPDFに出力される内容をレポートに記録する。
+	Preview bool
+	// Sheets はExcelでPDFに出力するシート。プレビューと、シートごとに出力する場合の増分変換で使用する。
+	Sheets *sheetSelector
+}
+
+// walkOptions は対象フォルダ root の辿り方を返す。
+func (o runOptions) walkOptions(root string) (walkOptions, error) {
+	filter, err := newPathFilter(root, o.Include, o.Exclude)
+	if err != nil {
+		return walkOptions{}, err
+	}
+	return walkOptions{
+		Filter:     filter,
+		MaxDepth:   o.MaxDepth,
+		SkipHidden: o.SkipHidden,
+		Symlinks:   o.Symlinks,
+	}, nil
+}
+
+// jobs は app を同時に起動するインスタンスの数を返す。
+func (o runOptions) jobs(app AppType) int {
+	if n := o.Jobs[app]; n > 0 {
+		return n
+	}
+	if o.DefaultJobs > 0 {
+		return o.DefaultJobs
+	}
+	return 1
+}
+
+// upToDate は増分変換で、path のファイルのPDF pdfPath が最新かどうかを返す。
+// マニフェストを使用する場合は、path のハッシュ値も返す。
+func (o runOptions) upToDate(path, pdfPath string) (ok bool, hash string, err error) {
+	// シートごとに出力する場合は、全てのシートのPDFを確認する。
+	pdfs := expectedPdfs(path, pdfPath, o.Sheets)
+	if o.Manifest != nil {
+		return o.Manifest.upToDate(path, pdfs...)
+	}
+	ok, err = isPdfUpToDate(path, pdfs...)
+	return ok, "", err
+}
+
+// runResult はPDF変換の実行結果。
+type runResult struct {
+	// Total はPDF変換対象ファイルの数。
+	Total int
+	// Converted はPDFに変換できたファイルの数。
+	Converted int
+	// Skipped はPDFが最新である、全てのシートが対象外であるなどの理由で、変換しなかったファイルの数。
+	Skipped int
+	// Errs は変換で発生したエラー。ファイルごとのエラーは *FileError。
+	Errs []error
+	// Files はファイルごとの結果。変換されなかったファイルは failed となる。
+	Files []*fileReport
+}
+
+// Failed はPDFに変換できなかったファイルの数を返す。
+func (r *runResult) Failed() int {
+	return r.Total - r.Converted - r.Skipped
+}
+
+// targets で指定されたフォルダとファイルのPDF変換対象ファイルを、newConverter で生成した
+// Converter を使用してPDFに変換する。Excel、Word、PowerPointの変換は並行して実行し、
+// それぞれ opts で指定された数のインスタンスで、ファイルを分担して変換する。
+// 変換対象ファイルの取得や出力先の決定に失敗した場合は、err を返す。
+func run(targets []string, newConverter converterFactory, opts runOptions) (*runResult, error) {
+	entries, err := buildPlan(targets, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Preview {
+		previewEntries(entries, opts.Sheets)
+	}
+
+	result := &runResult{Total: len(entries)}
+	// PDFに変換するファイルが存在しない場合は、処理終了。
+	if result.Total == 0 {
+		slog.Info("PDF変換対象フィルが存在しません。", "path", strings.Join(targets, ", "))
+		return result, nil
+	}
+
+	hashes := map[string]string{}
+	reports := map[string]*fileReport{}
+	for _, e := range entries {
+		hashes[e.Source] = e.hash
+		r := newFileReport(e)
+		reports[e.Source] = r
+		result.Files = append(result.Files, r)
+	}
+
+	var mu sync.Mutex
+	// skipped は変換を始めた後にスキップしたファイルの数。
+	var skipped int
+	pool := newWorkerPool(newConverter, opts)
+	pool.onDone = func(t task, r taskResult) {
+		mu.Lock()
+		reports[t.Path].setResult(r)
+		if r.Err == nil && r.SkipReason != "" {
+			skipped++
+		}
+		mu.Unlock()
+
+		if r.Err != nil || r.SkipReason != "" || opts.Manifest == nil {
+			return
+		}
+		if err := opts.Manifest.record(t.Path, hashes[t.Path]); err != nil {
+			slog.Warn("マニフェストに記録できませんでした。", "err", err, "path", t.Path)
+		}
+	}
+	var waiting []*planEntry
+	for _, e := range entries {
+		if e.Error != "" {
+			result.Errs = append(result.Errs, &FileError{Path: e.Source, Err: e.err})
+			continue
+		}
+		if e.Skip {
+			slog.Info(filepath.Base(e.Source)+" スキップ", "理由", e.Reason)
+			result.Skipped++
+			continue
+		}
+		if e.Locked && opts.Locked == lockedRetry {
+			waiting = append(waiting, e)
+			continue
+		}
+		pool.Submit(task{App: e.App, Path: e.Source, PdfPath: e.Target, Export: e.Export, Snapshot: e.Locked})
+	}
+
+	// 他のユーザーが開いているファイルは、他のファイルを変換している間に、閉じられるのを待って変換する。
+	for i := 0; i < opts.LockRetries && len(waiting) > 0; i++ {
+		time.Sleep(opts.LockWait)
+		var still []*planEntry
+		for _, e := range waiting {
+			if owner, locked := lockOwner(e.Source, e.App); locked {
+				e.LockedBy = owner
+				still = append(still, e)
+				continue
+			}
+			slog.Info(filepath.Base(e.Source) + " ファイルが閉じられたため変換します。")
+			pool.Submit(task{App: e.App, Path: e.Source, PdfPath: e.Target, Export: e.Export})
+		}
+		waiting = still
+	}
+	for _, e := range waiting {
+		slog.Warn(filepath.Base(e.Source)+" 他のユーザーが開いたままのためスキップします。", "ユーザー", e.LockedBy)
+		mu.Lock()
+		reports[e.Source].setSkipped(skipLocked, e.LockedBy)
+		mu.Unlock()
+		result.Skipped++
+	}
+	converted, errs := pool.Close()
+	result.Converted, result.Errs = converted, append(result.Errs, errs...)
+	result.Skipped += skipped
+	for _, r := range result.Files {
+		r.setNotConverted()
+	}
+
+	if opts.Manifest != nil {
+		if err := opts.Manifest.save(); err != nil {
+			result.Errs = append(result.Errs, err)
+		}
+	}
+	return result, nil
+}
+
+func convertFileToPdf() filepath.WalkFunc {
+	// var eFlag bool
+	// var wFlag, pFlag bool
+	// var excelApp *ole.IDispatch
+	// var wordApp, ppointApp *ole.IDispatch
+
+	return func(path string, info os.FileInfo, err error) (rErr error) {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || strings.HasPrefix(filepath.Base(path), "~") {
+			return nil
+		}
+		fullpath, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		ext := filepath.Ext(fullpath)
+		if ext != ".docx" && ext != ".xlsx" && ext != ".xls" && ext != ".pptx" {
+			return nil
+		}
+		// pdfPath := getPathWithoutExt(path) + ".pdf"
+		// pdfFullPath, err := filepath.Abs(pdfPath)
+		if err != nil {
+			return err
+		}
+
+		switch ext {
+		// case ".docx":
+		// 	if !wFlag {
+		// 		wFlag = true
+		// 		// Wordアプリケーションの作成
+		// 		wordApp, err2 = createWordApp()
+		// 		if err2 != nil {
+		// 			return err2
+		// 		}
+		// 		defer wordApp.Release()
+		// 	}
+		// 	err = convertDocxToPdf(wordApp, fullpath, pdfPath)
+		case ".xlsx", ".xls":
+			// if !eFlag {
+			// 	eFlag = true
+			// 	// Excelアプリケーションの生成
+			// 	excelApp, rErr = createExcelApp()
+			// 	if rErr != nil {
+			// 		return rErr
+			// 	}
+			// 	defer excelApp.Release()
+			// 	defer func() {
+			// 		_, err := oleutil.CallMethod(excelApp, "Quit")
+			// 		if err != nil {
+			// 			rErr = errors.Join(rErr, err)
+			// 		}
+			// 		slog.Info("Excel has exited.")
+			// 	}()
+			// 	slog.Info("Excel launched.")
+			// }
+			// rErr = convertXlsxToPdf(excelApp, fullpath, pdfFullPath)
+			// if rErr != nil {
+			// 	slog.Error("Failed to convert.", err, path, pdfPath)
+			// } else {
+			// 	slog.Info("Converted.", "excel-path", path, "pdf-path", pdfPath)
+			// }
+		case ".pptx":
+			// if !pFlag {
+			// 	pFlag = true
+			// 	// PowerPointオブジェクトの生成
+			// 	ppointApp, err2 = createPowerPointApp()
+			// 	if err2 != nil {
+			// 		return err2
+			// 	}
+			// 	defer ppointApp.Release()
+			// }
+			// err = convertPptxToPdf(ppointApp, fullpath, pdfPath)
+		}
+		return nil
+	}
+}
+
+// func convertDocxToPdf(docxPath string, pdfPath string) error {
+// doc, err := document.Open(docxPath)
+// if err != nil {
+// 	return err
+// }
+// defer doc.Close()
+// pdf, err := os.Create(pdfPath)
+// if err != nil {
+// 	return err
+// }
+// defer pdf.Close()
+// err = doc.Save(pdf, document.SaveOptionPDFPageWidth(8.5), document.SaveOptionPDFPageHeight(11))
+// if err != nil {
+// 	return err
+// }
+// 	return nil
+// }
+
+// func convertPptxToPdf(pptxPath string, pdfPath string) error {
+// 	// prs, err := presentation.Open(pptxPath)
+// 	// if err != nil {
+// 	// 	return err
+// 	// }
+// 	// defer prs.Close()
+// 	// pdf, err := os.Create(pdfPath)
+// 	// if err != nil {
+// 	// 	return err
+// 	// }
+// 	// defer pdf.Close()
+// 	// err = prs.SaveToPDF(pdf)
+// 	// if err != nil {
+// 	// 	return err
+// 	// }
+// 	return nil
+// }
+
+// func convertToPDF(filepath string) error {
+// 	// Wordオブジェクトを取得する
+// 	word, err := unknown.QueryInterface(ole.IID_IDispatch)
+// 	if err != nil {
+// 		return err
+// 	}
+// 	defer word.Release()
+// 	// Wordウィンドウを表示しないようにする
+// 	_, err = oleutil.PutProperty(word, "Visible", false)
+// 	if err != nil {
+// 		return err
+// 	}
+// 	// Wordドキュメントを開く
+// 	doc, err := oleutil.CallMethod(word, "Documents", "Open", filepath)
+// 	if err != nil {
+// 		return err
+// 	}
+// 	defer doc.Release()
+// 	// PDFに変換する
+// 	_, err = oleutil.CallMethod(doc, "ExportAsFixedFormat", filepath+".pdf", 17)
+// 	if err != nil {
+// 		return err
+// 	}
+// 	return nil
+// }
+
+// func convertToPDF(filePath string) error {
+// 	// PowerPointオブジェクトをPowerPoint.Applicationオブジェクトにキャスト
+// 	powerPoint, err := unknown.QueryInterface(ole.IID_IDispatch)
+// 	if err != nil {
+// 		return fmt.Errorf("PowerPoint.Applicationオブジェクトの生成に失敗しました: %v", err)
+// 	}
+// 	defer powerPoint.Release()
+// 	// プレゼンテーションの読み込み
+// 	presentation, err := oleutil.CallMethod(powerPoint, "Presentations", filePath)
+// 	if err != nil {
+// 		return fmt.Errorf("プレゼンテーションの読み込みに失敗しました: %v", err)
+// 	}
+// 	// PDFファイルへの変換
+// 	pdfFilePath := filePath + ".pdf"
+// 	_, err = oleutil.CallMethod(presentation.ToIDispatch(), "SaveAs", pdfFilePath, 32)
+// 	if err != nil {
+// 		return fmt.Errorf("PDFへの変換に失敗しました: %v", err)
+// 	}
+// 	return nil
+// }
+
+// pathから拡張子を除いたファイル名を返す
+func getFileNameWithoutExt(path string) string {
+	return filepath.Base(path[:len(path)-len(filepath.Ext(path))])
+}
+
+// patshから拡張子を除いたパスを返す
+func getPathWithoutExt(path string) string {
+	return path[:len(path)-len(filepath.Ext(path))]
+}
+
+// folderPath で指定されたフォルダから、サブフォルダも含めたPDF変換対象ファイルの一覧を取得する。
+// PDF変換対象ファイルの一覧は、Excel、Word、PowerPointに分けて、配列で返す。
+// フォルダの深さ、隠しフォルダ、シンボリックリンク、変換対象外のパターンは opts に従う。
+func getFilePaths(folderPath string, opts walkOptions) ([]string, []string, []string, error) {
+	var xslPaths, docPaths, pptPaths []string
+	err := walkFiles(folderPath, opts, func(path string, info os.FileInfo) {
+		// ~で始まるファイルはスキップ
+		if strings.HasPrefix(filepath.Base(path), "~") {
+			return
+		}
+		app, ok := detectAppType(path)
+		if !ok {
+			return
+		}
+		switch app {
+		case AppExcel:
+			xslPaths = append(xslPaths, path)
+		case AppWord:
+			docPaths = append(docPaths, path)
+		case AppPowerPoint:
+			pptPaths = append(pptPaths, path)
+		}
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return xslPaths, docPaths, pptPaths, nil
+}
+
+// path の拡張子から、formats に登録された形式のPDF変換に使用するアプリケーションを判定する。
+// 拡張子の大文字と小文字は区別しない。PDF変換対象外のファイルの場合は、ok に false を返す。
+func appTypeOf(path string) (app AppType, ok bool) {
+	spec, ok := formats.lookup(path)
+	return spec.App, ok
+}
+
+func usage() {
+	slog.Info("usage: PDFConverterGO [flags] path...")
+	slog.Info("       PDFConverterGO watch [flags] path...")
+	flag.PrintDefaults()
+}
+
+// 変換元ファイルのパスから、PDFファイルのパス（相対パス、絶対パス）を取得する。
+// 出力先フォルダが指定された場合は、変換対象フォルダからの相対的なフォルダ構成を再現する。
+func getPdfPath(path string, n pdfNaming) (string, string, error) {
+	dir := filepath.Dir(path)
+	if n.OutDir != "" {
+		rel, err := filepath.Rel(n.Root, dir)
+		if err != nil {
+			return "", "", err
+		}
+		dir = filepath.Join(n.OutDir, rel)
+	}
+
+	name := getFileNameWithoutExt(path) + ".pdf"
+	if n.Template != nil {
+		var err error
+		if name, err = n.Template.execute(path); err != nil {
+			return "", "", err
+		}
+	}
+
+	pdfPath := filepath.Join(dir, name)
+	pdfFullPath, err := filepath.Abs(pdfPath)
+	if err != nil {
+		return "", "", err
+	}
+	return pdfPath, pdfFullPath, nil
+}
@@ -1,29 +1,58 @@
-package main
-
-import (
-	"testing"
-)
-
-func TestGetFilePaths(t *testing.T) {
-	folderPath := "./testdata"
-
-	expectedFilePaths := []string{
-		"testdata\\test1.xlsx",
-		"testdata\\test2.xlsx",
-	}
-
-	xlsPaths, _, _, err := getFilePaths(folderPath)
-	if err != nil {
-		t.Errorf("Error occurred while getting file paths: %v", err)
-	}
-
-	if len(xlsPaths) != len(expectedFilePaths) {
-		t.Errorf("Number of file paths is incorrect. Expected: %d, Actual: %d", len(expectedFilePaths), len(xlsPaths))
-	}
-
-	for i, path := range xlsPaths {
-		if path != expectedFilePaths[i] {
-			t.Errorf("File path is incorrect. Expected: %s, Actual: %s", expectedFilePaths[i], path)
-		}
-	}
-}
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestGetFilePaths(t *testing.T) {
+	folderPath := "./testdata"
+
+	expectedFilePaths := []string{
+		filepath.Join("testdata", "test1.xlsx"),
+		filepath.Join("testdata", "test2.xlsx"),
+	}
+
+	xlsPaths, _, _, err := getFilePaths(folderPath, walkOptions{})
+	if err != nil {
+		t.Errorf("Error occurred while getting file paths: %v", err)
+	}
+
+	if len(xlsPaths) != len(expectedFilePaths) {
+		t.Errorf("Number of file paths is incorrect. Expected: %d, Actual: %d", len(expectedFilePaths), len(xlsPaths))
+	}
+
+	for i, path := range xlsPaths {
+		if path != expectedFilePaths[i] {
+			t.Errorf("File path is incorrect. Expected: %s, Actual: %s", expectedFilePaths[i], path)
+		}
+	}
+}
+
+func TestGetPdfPath(t *testing.T) {
+	root := filepath.Join("share", "docs")
+	tests := []struct {
+		path   string
+		outDir string
+		want   string
+	}{
+		{filepath.Join(root, "a.xlsx"), "", filepath.Join(root, "a.pdf")},
+		{filepath.Join(root, "sub", "b.docx"), "", filepath.Join(root, "sub", "b.pdf")},
+		{filepath.Join(root, "a.xlsx"), "out", filepath.Join("out", "a.pdf")},
+		{filepath.Join(root, "sub", "b.docx"), "out", filepath.Join("out", "sub", "b.pdf")},
+	}
+
+	for _, tt := range tests {
+		pdfPath, pdfFullPath, err := getPdfPath(tt.path, pdfNaming{Root: root, OutDir: tt.outDir})
+		if err != nil {
+			t.Errorf("getPdfPath(%q, %q) error: %v", tt.path, tt.outDir, err)
+			continue
+		}
+		if pdfPath != tt.want {
+			t.Errorf("getPdfPath(%q, %q) = %q, want %q", tt.path, tt.outDir, pdfPath, tt.want)
+		}
+		if !filepath.IsAbs(pdfFullPath) {
+			t.Errorf("getPdfPath(%q, %q) full path %q is not absolute", tt.path, tt.outDir, pdfFullPath)
+		}
+	}
+}
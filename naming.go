@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// テンプレートで使用できるプレースホルダ。
+const (
+	placeholderName   = "name"   // 拡張子を除いたファイル名
+	placeholderExt    = "ext"    // 先頭の . を除いた拡張子
+	placeholderParent = "parent" // 変換元ファイルのフォルダ名
+	placeholderDate   = "date"   // 変換元ファイルの更新日 (YYYYMMDD)
+	placeholderSheet  = "sheet"  // シートごとに出力する場合のシート名
+)
+
+// sheetMarker は計画の出力先に残す {sheet}。変換時に、シートごとのファイル名に置き換える。
+const sheetMarker = "{" + placeholderSheet + "}"
+
+// pdfNaming はPDFファイルの出力先と名前の決め方。
+type pdfNaming struct {
+	// Root は変換対象フォルダ。
+	Root string
+	// OutDir は出力先フォルダ。空の場合は、変換元ファイルと同じフォルダに出力する。
+	OutDir string
+	// Template はPDFファイル名のテンプレート。nil の場合は変換元ファイルと同じ名前にする。
+	Template *nameTemplate
+}
+
+// nameTemplate は -name で指定する、PDFファイル名のテンプレート。
+// {name}_{ext}.pdf のように、{} で囲んだプレースホルダを変換元ファイルの情報に置き換える。
+// / で区切ると、出力先のサブフォルダを指定できる。
+type nameTemplate struct {
+	text  string
+	parts []templatePart
+}
+
+// templatePart はテンプレートの固定の文字列、またはプレースホルダ。
+type templatePart struct {
+	literal     string
+	placeholder string
+}
+
+// defaultNameTemplate は変換元ファイルと同じ名前のPDFを出力するテンプレート。
+const defaultNameTemplate = "{name}.pdf"
+
+// parseNameTemplate はテンプレートを解析し、一意なファイル名を生成できるかを検証する。
+// 拡張子 .pdf が無い場合は、末尾に追加する。
+// {sheet} は splitSheets が true (-split-sheets) の場合のみ、フォルダ名やファイル名の一部として使用できる。
+func parseNameTemplate(text string, splitSheets bool) (*nameTemplate, error) {
+	if text == "" {
+		text = defaultNameTemplate
+	}
+	if !strings.EqualFold(filepath.Ext(text), ".pdf") {
+		text += ".pdf"
+	}
+
+	t := &nameTemplate{text: text}
+	used := map[string]bool{}
+	rest := text
+	for rest != "" {
+		i := strings.IndexAny(rest, "{}")
+		if i < 0 {
+			t.parts = append(t.parts, templatePart{literal: rest})
+			break
+		}
+		if rest[i] == '}' {
+			return nil, fmt.Errorf("テンプレートの { と } が対応していません: %s", text)
+		}
+		if i > 0 {
+			t.parts = append(t.parts, templatePart{literal: rest[:i]})
+		}
+		j := strings.IndexAny(rest[i+1:], "{}")
+		if j < 0 || rest[i+1+j] != '}' {
+			return nil, fmt.Errorf("テンプレートの { と } が対応していません: %s", text)
+		}
+		name := rest[i+1 : i+1+j]
+		switch name {
+		case placeholderName, placeholderExt, placeholderParent, placeholderDate:
+		case placeholderSheet:
+			if !splitSheets {
+				return nil, errors.New("{sheet} は -split-sheets を指定した場合のみ使用できます。")
+			}
+		default:
+			return nil, fmt.Errorf("テンプレートに不明なプレースホルダがあります: {%s}", name)
+		}
+		used[name] = true
+		t.parts = append(t.parts, templatePart{placeholder: name})
+		rest = rest[i+1+j+1:]
+	}
+
+	// ファイル名を変換元ファイルごとに変えるため、{name} は必須とする。
+	if !used[placeholderName] {
+		return nil, fmt.Errorf("テンプレートには {name} が必要です: %s", text)
+	}
+	for _, p := range t.parts {
+		if strings.ContainsAny(p.literal, `\:*?"<>|`) {
+			return nil, fmt.Errorf("テンプレートにファイル名に使用できない文字があります: %s", text)
+		}
+	}
+	if strings.HasPrefix(text, "/") {
+		return nil, fmt.Errorf("テンプレートに絶対パスは指定できません: %s", text)
+	}
+	elems := strings.Split(text, "/")
+	for i, elem := range elems {
+		if elem == "" || elem == "." || elem == ".." {
+			return nil, fmt.Errorf("テンプレートのフォルダの指定が正しくありません: %s", text)
+		}
+		// シートの無いファイルでも空のフォルダ名やファイル名にならないように、{sheet} だけの指定は認めない。
+		if i == len(elems)-1 {
+			elem = elem[:len(elem)-len(filepath.Ext(elem))]
+		}
+		if elem == sheetMarker {
+			return nil, fmt.Errorf("{sheet} は他の文字と組み合わせて指定してください: %s", text)
+		}
+	}
+	return t, nil
+}
+
+// execute は変換元ファイル path に対応するPDFファイルの、出力先フォルダからの相対パスを返す。
+// {sheet} はシート名が分かるまで sheetMarker のまま残す。
+func (t *nameTemplate) execute(path string) (string, error) {
+	var b strings.Builder
+	for _, p := range t.parts {
+		if p.placeholder == "" {
+			b.WriteString(p.literal)
+			continue
+		}
+		switch p.placeholder {
+		case placeholderName:
+			b.WriteString(getFileNameWithoutExt(path))
+		case placeholderExt:
+			b.WriteString(strings.TrimPrefix(filepath.Ext(path), "."))
+		case placeholderParent:
+			dir, err := filepath.Abs(filepath.Dir(path))
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(filepath.Base(dir))
+		case placeholderDate:
+			info, err := os.Stat(path)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(info.ModTime().Format("20060102"))
+		case placeholderSheet:
+			b.WriteString(sheetMarker)
+		}
+	}
+	return filepath.FromSlash(b.String()), nil
+}
+
+func (t *nameTemplate) String() string {
+	return t.text
+}
+
+// splitSheetsTarget はシートごとに出力する場合の出力先を返す。
+// target に {sheet} が無い場合は、budget__{sheet}.pdf のように拡張子の前に追加する。
+func splitSheetsTarget(target string) string {
+	if strings.Contains(target, sheetMarker) {
+		return target
+	}
+	return getPathWithoutExt(target) + "__" + sheetMarker + filepath.Ext(target)
+}
+
+// sheetPdfPath は出力先 target の {sheet} をシート名に置き換えたパスを返す。
+// used は同じブックで出力済みのパス (小文字) で、ファイル名が重複する場合は連番を付ける。
+func sheetPdfPath(target, sheet string, used map[string]bool) string {
+	base := strings.ReplaceAll(target, sheetMarker, sheetFileName(sheet))
+	path := base
+	for i := 2; used[strings.ToLower(path)]; i++ {
+		path = addPdfSuffix(base, strconv.Itoa(i))
+	}
+	used[strings.ToLower(path)] = true
+	return path
+}
+
+// windowsReservedNames は Windows でファイル名に使用できないデバイス名。
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// sheetFileName はシート名を、ファイル名に使用できる文字列にする。
+// ファイル名に使用できない文字と制御文字は _ に置き換え、末尾の空白と . は取り除く。
+func sheetFileName(sheet string) string {
+	name := strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(`\/:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, sheet)
+	name = strings.TrimRight(name, " .")
+	if name == "" {
+		return "_"
+	}
+	if windowsReservedNames[strings.ToUpper(name)] {
+		name += "_"
+	}
+	return name
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseNameTemplate(t *testing.T) {
+	tests := []struct {
+		text    string
+		split   bool
+		want    string
+		wantErr bool
+	}{
+		{"", false, "{name}.pdf", false},
+		{"{name}_{ext}", false, "{name}_{ext}.pdf", false},
+		{"{date}_{name}.pdf", false, "{date}_{name}.pdf", false},
+		{"{parent}/{name}.PDF", false, "{parent}/{name}.PDF", false},
+		{"{ext}.pdf", false, "", true},
+		{"{name}_{size}.pdf", false, "", true},
+		{"{name.pdf", false, "", true},
+		{"name}.pdf", false, "", true},
+		{"{name}_{sheet}.pdf", false, "", true},
+		{"{name}_{sheet}.pdf", true, "{name}_{sheet}.pdf", false},
+		{"{name}/{sheet}", true, "", true},
+		{"{name}/{sheet}.PDF", true, "", true},
+		{"{sheet}/{name}.pdf", true, "", true},
+		{"{name}/{sheet}_{ext}.pdf", true, "{name}/{sheet}_{ext}.pdf", false},
+		{"../{name}.pdf", false, "", true},
+		{"/tmp/{name}.pdf", false, "", true},
+		{"{name}?.pdf", false, "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseNameTemplate(tt.text, tt.split)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseNameTemplate(%q) succeeded, want error", tt.text)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseNameTemplate(%q) error: %v", tt.text, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("parseNameTemplate(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestNameTemplateExecute(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "budget")
+	writeFiles(t, dir, "plan.xlsx")
+	path := filepath.Join(dir, "plan.xlsx")
+	mtime := time.Date(2023, 4, 1, 9, 0, 0, 0, time.Local)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"{name}.pdf", "plan.pdf"},
+		{"{name}_{ext}.pdf", "plan_xlsx.pdf"},
+		{"{date}_{name}.pdf", "20230401_plan.pdf"},
+		{"{parent}/{name}.pdf", filepath.Join("budget", "plan.pdf")},
+		{"{name}_{sheet}.pdf", "plan_{sheet}.pdf"},
+	}
+
+	for _, tt := range tests {
+		tmpl, err := parseNameTemplate(tt.text, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := tmpl.execute(path)
+		if err != nil {
+			t.Errorf("execute(%q) error: %v", tt.text, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("execute(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSheetFileName(t *testing.T) {
+	tests := []struct {
+		sheet string
+		want  string
+	}{
+		{"Summary", "Summary"},
+		{"予算 (2023)", "予算 (2023)"},
+		{`a<b>"c"|d`, "a_b__c__d"},
+		{"tab\tname", "tab_name"},
+		{"Notes. ", "Notes"},
+		{"...", "_"},
+		{"con", "con_"},
+		{"LPT1", "LPT1_"},
+	}
+	for _, tt := range tests {
+		if got := sheetFileName(tt.sheet); got != tt.want {
+			t.Errorf("sheetFileName(%q) = %q, want %q", tt.sheet, got, tt.want)
+		}
+	}
+}
+
+func TestSheetPdfPath(t *testing.T) {
+	target := filepath.Join("out", "budget__{sheet}.pdf")
+	used := map[string]bool{}
+	got := []string{
+		sheetPdfPath(target, "Summary", used),
+		sheetPdfPath(target, "a<b", used),
+		sheetPdfPath(target, "a>b", used),
+		sheetPdfPath(target, "SUMMARY", used),
+	}
+	want := []string{
+		filepath.Join("out", "budget__Summary.pdf"),
+		filepath.Join("out", "budget__a_b.pdf"),
+		filepath.Join("out", "budget__a_b_2.pdf"),
+		filepath.Join("out", "budget__SUMMARY_2.pdf"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sheetPdfPath = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,701 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/go-ole/go-ole"
+	"github.com/go-ole/go-ole/oleutil"
+	"golang.org/x/exp/slog"
+)
+
+const (
+	MsoTriStateMsoFalse = 0
+	MsoTriStateMsoTrue  = -1
+)
+
+// Open と ExportAsFixedFormat の引数に指定する定数。
+const (
+	// Excel
+	xlSheetVisible     = -1
+	xlSheetHidden      = 0
+	xlSheetVeryHidden  = 2
+	xlUpdateLinksNever = 0
+	xlFormatNone       = 5
+	xlTypePDF          = 0
+	xlQualityStandard  = 0
+	xlQualityMinimum   = 1
+
+	// Word
+	wdExportFormatPDF           = 17
+	wdExportOptimizeForPrint    = 0
+	wdExportOptimizeForOnScreen = 1
+	wdExportAllDocument         = 0
+	wdExportDocumentContent     = 0
+
+	// PowerPoint
+	ppFixedFormatTypePDF        = 2
+	ppFixedFormatIntentScreen   = 1
+	ppFixedFormatIntentPrint    = 2
+	ppPrintHandoutVerticalFirst = 1
+	ppPrintOutputSlides         = 1
+	ppPrintAll                  = 1
+)
+
+// パスワードが正しくない場合に、ファイルを開く Open が返す例外のエラーコード (EXCEPINFO の scode)。
+const (
+	// Excel の実行時エラー 1004
+	xlErrWrongPassword = 0x800A03EC
+	// Word の実行時エラー 5408
+	wdErrWrongPassword = 0x800A1520
+	// PowerPoint はパスワードが正しくない場合も、ファイルを開けない場合と同じ E_FAIL を返す。
+	ppErrWrongPassword = 0x80004005
+)
+
+// isOleError は、エラーが COM の例外で、エラーコードが scode の場合に true を返す関数を返す。
+func isOleError(scode uint32) func(error) bool {
+	return func(err error) bool {
+		var oleErr *ole.OleError
+		if !errors.As(err, &oleErr) {
+			return false
+		}
+		info, ok := oleErr.SubError().(ole.EXCEPINFO)
+		return ok && info.SCODE() == scode
+	}
+}
+
+// msoTriState は bool を MsoTriState に変換する。
+func msoTriState(b bool) int {
+	if b {
+		return MsoTriStateMsoTrue
+	}
+	return MsoTriStateMsoFalse
+}
+
+// oleConverter は COM 経由で Excel、Word、PowerPoint を操作する Converter。
+type oleConverter struct {
+	app    AppType
+	sheets *sheetSelector
+	// passwords はパスワードで保護されたファイルを開く時に試すパスワード。
+	passwords []string
+	dispatch  *ole.IDispatch
+	// pid はアプリケーションのプロセスID。取得できない場合は 0。
+	pid int
+}
+
+// newOleConverter は app の種類に応じた COM の Converter を生成する。
+// sheets は Excel で PDF に出力するシート。
+func newOleConverter(app AppType, sheets *sheetSelector, passwords []string) *oleConverter {
+	return &oleConverter{app: app, sheets: sheets, passwords: passwords}
+}
+
+// Open はCOMを初期化し、Officeアプリケーションを起動する。
+func (c *oleConverter) Open() error {
+	// COMオブジェクトの初期化
+	if err := ole.CoInitializeEx(0, ole.COINIT_MULTITHREADED); err != nil {
+		return err
+	}
+
+	var err error
+	switch c.app {
+	case AppExcel:
+		c.dispatch, err = createExcelApp()
+	case AppWord:
+		c.dispatch, c.pid, err = startWordApp()
+	case AppPowerPoint:
+		c.dispatch, err = createPowerPointApp()
+	default:
+		err = fmt.Errorf("未対応のアプリケーションです: %v", c.app)
+	}
+	if err != nil {
+		ole.CoUninitialize()
+		return err
+	}
+
+	if c.app == AppWord {
+		// Wordウィンドウを表示しないようにする
+		if _, err := oleutil.PutProperty(c.dispatch, "Visible", false); err != nil {
+			return errors.Join(err, c.Quit())
+		}
+	}
+
+	if c.pid == 0 {
+		c.pid = c.processID()
+	}
+
+	slog.Info(c.app.String()+"を起動しました.", "pid", c.pid)
+	return nil
+}
+
+// processID はアプリケーションのウィンドウから、プロセスIDを取得する。
+// Wordはアプリケーションのウィンドウハンドルを取得できないため、0 を返す。Wordは startWordApp で取得する。
+func (c *oleConverter) processID() int {
+	var name string
+	switch c.app {
+	case AppExcel:
+		name = "Hwnd"
+	case AppPowerPoint:
+		name = "HWND"
+	default:
+		return 0
+	}
+
+	hwnd, err := oleutil.GetProperty(c.dispatch, name)
+	if err != nil {
+		return 0
+	}
+	pid, err := processIDFromWindow(uintptr(hwnd.Val))
+	if err != nil {
+		slog.Warn(c.app.String()+"のプロセスIDを取得できませんでした。", "err", err)
+		return 0
+	}
+	return pid
+}
+
+// Convert は src のファイルをPDFに変換し、dst に出力する。
+// COMの呼び出しは中断できないため、ctx は使用しない。
+func (c *oleConverter) Convert(ctx context.Context, src, dst string, export exportOptions) (ConvertResult, error) {
+	// パスワードは、パスワードで保護されたファイルを開く場合のみ指定する。
+	var passwords []string
+	if len(c.passwords) > 0 {
+		if encrypted, err := isEncrypted(src); err == nil && encrypted {
+			passwords = c.passwords
+		}
+	}
+
+	var count int
+	var err error
+	switch c.app {
+	case AppExcel:
+		return convertXlsxToPdf(c.dispatch, src, dst, c.sheets, passwords, export)
+	case AppWord:
+		err = convertDocxToPdf(c.dispatch, src, dst, passwords, export)
+	case AppPowerPoint:
+		count, err = convertPptxToPdf(c.dispatch, src, dst, passwords, export)
+	default:
+		err = fmt.Errorf("未対応のアプリケーションです: %v", c.app)
+	}
+	return ConvertResult{Count: count}, err
+}
+
+// Quit はOfficeアプリケーションを終了し、COMの利用を終了する。
+func (c *oleConverter) Quit() error {
+	if c.dispatch == nil {
+		return nil
+	}
+	defer ole.CoUninitialize()
+	defer func() {
+		c.dispatch.Release()
+		c.dispatch = nil
+	}()
+
+	if _, err := oleutil.CallMethod(c.dispatch, "Quit"); err != nil {
+		return err
+	}
+	slog.Info(c.app.String() + "を終了しました.")
+	return nil
+}
+
+// Kill はアプリケーションのプロセスを強制終了する。
+// 変換中に他のゴルーチンから呼び出すため、COMは使用しない。後始末は Release で行う。
+func (c *oleConverter) Kill() error {
+	if c.pid == 0 {
+		return fmt.Errorf("%sのプロセスIDが不明なため、強制終了できません。", c.app)
+	}
+	p, err := os.FindProcess(c.pid)
+	if err != nil {
+		return err
+	}
+	if err := p.Kill(); err != nil {
+		return err
+	}
+	slog.Info(c.app.String()+"を強制終了しました.", "pid", c.pid)
+	return nil
+}
+
+// Release は強制終了したアプリケーションの参照を解放し、COMの利用を終了する。
+// Open と同じスレッドで呼び出す。
+func (c *oleConverter) Release() {
+	if c.dispatch == nil {
+		return
+	}
+	c.dispatch.Release()
+	c.dispatch = nil
+	ole.CoUninitialize()
+}
+
+// PowerPointファイルをPDFに変換し、スライド数を返す
+func convertPptxToPdf(powerpoint *ole.IDispatch, pptPath, pdfFilePath string, passwords []string, export exportOptions) (_ int, err error) {
+	pptname := filepath.Base(pptPath)
+
+	// 　 Dim ppt As New PowerPoint.Application
+	// 　 Dim pres As PowerPoint.Presentation
+	// 　 Dim save_path As String, file_name As String
+	// 　 Dim Target As String
+	// 　 Target = Application.GetOpenFilename("PowerPoint,*.pptx")
+	// 　 If Target = "False" Then Exit Sub
+	// 　 Set pres = ppt.Presentations.Open(Target, WithWindow:=MsoTriState.msoFalse)
+	//
+	// 　 With pres
+	// 　　 save_path = CreateObject("WScript.Shell").SpecialFolders("Desktop")
+	// 　　 file_name = "Test"
+	// 　　 .ExportAsFixedFormat _
+	// 　　　　　 Path:=save_path & "\" & file_name & ".pdf", _
+	// 　　　　　 FixedFormatType:=ppFixedFormatTypePDF
+	// 　 End With
+
+	pres, err := oleutil.GetProperty(powerpoint, "Presentations")
+	if err != nil {
+		return 0, err
+	}
+	defer pres.ToIDispatch().Release()
+
+	// PowerPointドキュメントを開く
+	var ppt *ole.IDispatch
+	err = tryPasswords(passwords, isOleError(ppErrWrongPassword), func(password string) error {
+		var err error
+		ppt, err = openPptFile(pres.ToIDispatch(), pptPath, password)
+		return err
+	})
+	// openPptFile のエラーは ErrOpenFile で、どのパスワードでも開けなかった場合は ErrPassword となる。
+	if err != nil {
+		return 0, err
+	}
+	defer ppt.Release()
+	// 変換に失敗した場合も、プレゼンテーションを開いたままにしないように閉じる。
+	defer func() {
+		if cerr := closePresentation(ppt); err == nil {
+			err = cerr
+		}
+	}()
+
+	slides, err := oleutil.GetProperty(ppt, "Slides")
+	if err != nil {
+		return 0, err
+	}
+	defer slides.ToIDispatch().Release()
+
+	count := (int)(oleutil.MustGetProperty(slides.ToIDispatch(), "Count").Val)
+	slog.Info(pptname, "スライド数", count)
+
+	ps, err := oleutil.GetProperty(ppt, "PageSetup")
+	if err != nil {
+		return 0, err
+	}
+	defer ps.ToIDispatch().Release()
+
+	sp := (int)(oleutil.MustGetProperty(ps.ToIDispatch(), "FirstSlideNumber").Val)
+	slog.Info(pptname, "スライド開始ページ番号", sp)
+
+	po, err := oleutil.GetProperty(ppt, "PrintOptions")
+	if err != nil {
+		return 0, err
+	}
+	defer po.ToIDispatch().Release()
+
+	r, err := oleutil.GetProperty(po.ToIDispatch(), "Ranges")
+	if err != nil {
+		return 0, err
+	}
+	defer r.ToIDispatch().Release()
+
+	// pr, err := oleutil.CallMethod(r.ToIDispatch(), "Add", 1, count)
+	pr, err := oleutil.CallMethod(r.ToIDispatch(), "Add", sp, count+(sp-1))
+	if err != nil {
+		return 0, err
+	}
+	// defer pr.ToIDispatch().Release()
+
+	// PDFに変換する
+	// ExportAsFixedFormat (
+	// 	Path,
+	// 	FixedFormatType, : ppFixedFormatTypePDF(2)
+	// 	Intent, : ppFixedFormatIntentPrint(2)
+	// 	FrameSlides, : msoFalse(0)
+	// 	HandoutOrder, : ppPrintHandoutVerticalFirst(1)
+	// 	OutputType, : ppPrintOutputSlides(1)
+	// 	PrintHiddenSlides, : msoFalse(0)
+	// 	PrintRange,
+	// 	RangeType, : ppPrintAll(1)
+	// 	SlideShowName, : ""
+	// 	IncludeDocProperties, : false
+	// 	KeepIRMSettings, : false
+	// 	DocStructureTags, : false
+	// 	BitmapMissingFonts, : false
+	// 	UseISO19005_1, : false
+	// 	ExternalExporter : nil
+	//)
+	// _, err = oleutil.CallMethod(ppt.ToIDispatch(), "ExportAsFixedFormat", pdfFilePath, 2, 2, 0, 1, 1, 0, pr, 1, "", false, false, false, false, false, nil)
+	intent := ppFixedFormatIntentPrint
+	if export.Quality == qualityMinimum {
+		intent = ppFixedFormatIntentScreen
+	}
+	_, err = oleutil.CallMethod(ppt, "ExportAsFixedFormat", pdfFilePath, ppFixedFormatTypePDF, intent, MsoTriStateMsoFalse,
+		ppPrintHandoutVerticalFirst, ppPrintOutputSlides, msoTriState(export.PrintHiddenSlides), pr, ppPrintAll, "",
+		export.IncludeDocProperties, false, false, false, false)
+	//   ppFixedFormatTypePDF, ppFixedFormatIntentScreen, msoCTrue, ppPrintHandoutHorizontalFirst, ppPrintOutputBuildSlides, msoFalse, , , , False, False, False, False, False
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrConvertPdf, err.Error())
+	}
+
+	return count, nil
+}
+
+// closePresentation は変更を保存せずにプレゼンテーションを閉じる。
+func closePresentation(ppt *ole.IDispatch) error {
+	if _, err := oleutil.PutProperty(ppt, "Saved", true); err != nil {
+		return err
+	}
+	_, err := oleutil.CallMethod(ppt, "Close")
+	return err
+}
+
+// PowerPointのファイルをオープンする。
+// Presentations.Open はパスワードを指定できないため、password はファイル名の後に ::password:: の形式で指定する。
+// エラーのメッセージにはパスワードを含めない。
+func openPptFile(pres *ole.IDispatch, path, password string) (*ole.IDispatch, error) {
+	name := path
+	if password != "" {
+		name += "::" + password + "::"
+	}
+	// Open (FileName、 ReadOnly、 Untitled、 WithWindow)
+	//  FileName	必須	文字列型 (String)	開くファイルの名前を指定します。
+	//  ReadOnly	省略可能	MsoTriState	読み取り/書き込み可能な状態でファイルを開くか、または読み取り専用で開くかを指定します。
+	//		msoFalse	既定値です。 読み取り/書き込み可能な状態でファイルを開きます。
+	//		msoTrue	読み取り専用でファイルを開きます。
+	//  Untitled	省略可能	MsoTriState	ファイルにタイトルを設定するかどうかを指定します。
+	//		msoFalse	既定値です。 ファイル名が自動的に、開かれたプレゼンテーションのタイトルとなります。
+	//		msoTrue	タイトルなしにファイルを開きます。 これは、ファイルのコピーを作成することと同じです。
+	//  WithWindow	省略可能	MsoTriState	ファイルを表示するかどうかを指定します。
+	//		msoFalse	開かれたプレゼンテーションを非表示にします。
+	//		msoTrue	既定値です。 ファイルを表示可能なウィンドウで開きます。
+	ppt, err := oleutil.CallMethod(pres, "Open", name, MsoTriStateMsoTrue, MsoTriStateMsoFalse, MsoTriStateMsoFalse)
+	// ppt, err := oleutil.CallMethod(pres, "Open", path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOpenFile, hidePassword(err, password))
+	}
+	return ppt.ToIDispatch(), nil
+}
+
+func createPrintRange(pptname string, ppt *ole.VARIANT) (*ole.VARIANT, error) {
+	ps, err := oleutil.GetProperty(ppt.ToIDispatch(), "PageSetup")
+	if err != nil {
+		return nil, err
+	}
+	defer ps.ToIDispatch().Release()
+
+	slides, err := oleutil.GetProperty(ppt.ToIDispatch(), "Slides")
+	if err != nil {
+		return nil, err
+	}
+	defer slides.ToIDispatch().Release()
+
+	count := (int)(oleutil.MustGetProperty(slides.ToIDispatch(), "Count").Val)
+	slog.Info(pptname, "スライド数", count)
+
+	sp := (int)(oleutil.MustGetProperty(ps.ToIDispatch(), "FirstSlideNumber").Val)
+	slog.Info(pptname, "スライド開始ページ番号", sp)
+
+	po, err := oleutil.GetProperty(ppt.ToIDispatch(), "PrintOptions")
+	if err != nil {
+		return nil, err
+	}
+
+	defer po.ToIDispatch().Release()
+	r, err := oleutil.GetProperty(po.ToIDispatch(), "Ranges")
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.ToIDispatch().Release()
+	// pr, err := oleutil.CallMethod(r.ToIDispatch(), "Add", 1, count)
+	pr, err := oleutil.CallMethod(r.ToIDispatch(), "Add", sp, count+(sp-1))
+	if err != nil {
+		return nil, err
+	}
+	// defer pr.ToIDispatch().Release()
+
+	return pr, nil
+}
+
+// WordファイルをPDFに変換する
+func convertDocxToPdf(word *ole.IDispatch, dcPath, pdfFilePath string, passwords []string, export exportOptions) (err error) {
+	documents, err := oleutil.GetProperty(word, "documents")
+	if err != nil {
+		return err
+	}
+	defer documents.ToIDispatch().Release()
+
+	// Wordドキュメントを開く
+	// Open (FileName, ConfirmConversions, ReadOnly, AddToRecentFiles, PasswordDocument)
+	// ConfirmConversions: .rtf や .odt の変換確認のダイアログを表示しない。
+	var doc *ole.VARIANT
+	err = tryPasswords(passwords, isOleError(wdErrWrongPassword), func(password string) error {
+		var err error
+		if password == "" {
+			doc, err = oleutil.CallMethod(documents.ToIDispatch(), "Open", dcPath, false)
+		} else {
+			doc, err = oleutil.CallMethod(documents.ToIDispatch(), "Open", dcPath, false, false, false, password)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	defer doc.ToIDispatch().Release()
+	// 変換に失敗した場合も、文書を開いたままにしないように、変更を保存せずに閉じる。
+	defer func() {
+		if _, cerr := oleutil.CallMethod(doc.ToIDispatch(), "Close", false); err == nil {
+			err = cerr
+		}
+	}()
+
+	optimizeFor := wdExportOptimizeForPrint
+	if export.Quality == qualityMinimum {
+		optimizeFor = wdExportOptimizeForOnScreen
+	}
+	// PDFに変換する
+	// ExportAsFixedFormat (OutputFileName, ExportFormat, OpenAfterExport, OptimizeFor, Range, From, To, Item, IncludeDocProps)
+	_, err = oleutil.CallMethod(doc.ToIDispatch(), "ExportAsFixedFormat", pdfFilePath, wdExportFormatPDF, false, optimizeFor,
+		wdExportAllDocument, 1, 1, wdExportDocumentContent, export.IncludeDocProperties)
+	return err
+}
+
+// ExcelファイルをPDFに変換し、PDFに出力したシートを返す
+// ワークシートとグラフシートを対象とし、sheets により変換対象外となるシートは出力しない
+// export.SplitSheets の場合はシートごとにPDFを出力し、出力したPDFファイルのパスも返す
+func convertXlsxToPdf(excel *ole.IDispatch, xlPath, pdfFilePath string, sheets *sheetSelector, passwords []string, export exportOptions) (_ ConvertResult, err error) {
+	xlname := filepath.Base(xlPath)
+	workbooks, err := oleutil.GetProperty(excel, "Workbooks")
+	if err != nil {
+		return ConvertResult{}, err
+	}
+	defer workbooks.ToIDispatch().Release()
+	// Open (FileName, UpdateLinks, ReadOnly, Format, Password)
+	var workbook *ole.VARIANT
+	err = tryPasswords(passwords, isOleError(xlErrWrongPassword), func(password string) error {
+		var err error
+		if password == "" {
+			workbook, err = oleutil.CallMethod(workbooks.ToIDispatch(), "Open", xlPath)
+		} else {
+			workbook, err = oleutil.CallMethod(workbooks.ToIDispatch(), "Open", xlPath, xlUpdateLinksNever, false, xlFormatNone, password)
+		}
+		return err
+	})
+	if err != nil {
+		return ConvertResult{}, err
+	}
+	defer workbook.ToIDispatch().Release()
+	// 変換に失敗した場合も、ブックを開いたままにしないように閉じる。
+	defer func() {
+		if cerr := closeWorkbook(workbook.ToIDispatch()); err == nil {
+			err = cerr
+		}
+	}()
+
+	quality := xlQualityStandard
+	if export.Quality == qualityMinimum {
+		quality = xlQualityMinimum
+	}
+
+	// Worksheets にはグラフシートなどが含まれないため、Sheets を使用する。
+	kinds, err := sheetKindsOf(workbook.ToIDispatch())
+	if err != nil {
+		return ConvertResult{}, err
+	}
+	allSheets, err := oleutil.GetProperty(workbook.ToIDispatch(), "Sheets")
+	if err != nil {
+		return ConvertResult{}, err
+	}
+	defer allSheets.ToIDispatch().Release()
+
+	sheetCount := (int)(oleutil.MustGetProperty(allSheets.ToIDispatch(), "Count").Val)
+	slog.Info(xlname, "シート数", sheetCount)
+
+	// 出力するシートを選択する。最初のシートは選択を置き換え、以降のシートは選択に追加する。
+	// シートごとに出力する場合は、選択せずにシートごとにPDF形式で保存する。
+	var res ConvertResult
+	used := map[string]bool{}
+	for i := 1; i < sheetCount+1; i++ {
+		sheet := oleutil.MustGetProperty(allSheets.ToIDispatch(), "Item", i).ToIDispatch()
+		defer sheet.Release()
+		name := oleutil.MustGetProperty(sheet, "Name").ToString()
+		kind := kinds[name]
+		if kind == "" {
+			kind = sheetKindWorksheet
+		}
+		// 非表示のシートは選択できず、公開するつもりの無い表であることが多いため出力しない。
+		if sheets.excludesHidden() {
+			switch oleutil.MustGetProperty(sheet, "Visible").Val {
+			case xlSheetHidden:
+				slog.Info(xlname+" 非表示のシートのためスキップ", "シート名", name, "種類", kind, "表示", "hidden")
+				continue
+			case xlSheetVeryHidden:
+				slog.Info(xlname+" 非表示のシートのためスキップ", "シート名", name, "種類", kind, "表示", "veryHidden")
+				continue
+			}
+		}
+		if !sheets.selected(name) {
+			slog.Info(xlname+" シート名によりスキップ", "シート名", name, "種類", kind)
+			continue
+		}
+		if export.SplitSheets {
+			sheetPdf := sheetPdfPath(pdfFilePath, name, used)
+			if err := os.MkdirAll(filepath.Dir(sheetPdf), 0o755); err != nil {
+				return ConvertResult{}, err
+			}
+			_, err := oleutil.CallMethod(sheet, "ExportAsFixedFormat", xlTypePDF, sheetPdf, quality, export.IncludeDocProperties, export.IgnorePrintAreas)
+			if err != nil {
+				return ConvertResult{}, err
+			}
+			slog.Info(xlname+" シートを出力", "シート名", name, "種類", kind, "PDFファイル", sheetPdf)
+			res.Outputs = append(res.Outputs, sheetPdf)
+		} else {
+			_, err := oleutil.CallMethod(sheet, "Select", res.Count == 0)
+			if err != nil {
+				return ConvertResult{}, err
+			}
+			slog.Debug(xlname+" シートを選択", "シート名", name, "種類", kind)
+		}
+		res.Sheets = append(res.Sheets, exportedSheet{Name: name, Kind: kind})
+		res.Count++
+	}
+	// 全てのシートが対象外の場合は、-dry-run で対象外シートとして表示される状態と同じく、スキップとする。
+	if res.Count == 0 {
+		return ConvertResult{SkipReason: skipNoSheets}, nil
+	}
+	if export.SplitSheets {
+		return res, nil
+	}
+
+	activeSheet, err := oleutil.GetProperty(workbook.ToIDispatch(), "ActiveSheet")
+	if err != nil {
+		return ConvertResult{}, err
+	}
+	defer activeSheet.ToIDispatch().Release()
+
+	// 選択したシートをPDF形式で保存
+	_, err = oleutil.CallMethod(activeSheet.ToIDispatch(), "ExportAsFixedFormat", xlTypePDF, pdfFilePath, quality, export.IncludeDocProperties, export.IgnorePrintAreas)
+	if err != nil {
+		return ConvertResult{}, err
+	}
+
+	return res, nil
+}
+
+// sheetKindsOf はブックのワークシート以外のシートの、シート名ごとの種類を返す。
+// 種類は計画のプレビュー (internal/ooxml) と同じ名前にする。
+func sheetKindsOf(workbook *ole.IDispatch) (map[string]string, error) {
+	kinds := map[string]string{}
+	for _, c := range []struct {
+		property string
+		kind     string
+	}{
+		{"Charts", sheetKindChart},
+		{"DialogSheets", sheetKindDialog},
+		{"Excel4MacroSheets", sheetKindMacro},
+		{"Excel4IntlMacroSheets", sheetKindMacro},
+	} {
+		list, err := oleutil.GetProperty(workbook, c.property)
+		if err != nil {
+			return nil, err
+		}
+		count := (int)(oleutil.MustGetProperty(list.ToIDispatch(), "Count").Val)
+		for i := 1; i < count+1; i++ {
+			sheet := oleutil.MustGetProperty(list.ToIDispatch(), "Item", i).ToIDispatch()
+			kinds[oleutil.MustGetProperty(sheet, "Name").ToString()] = c.kind
+			sheet.Release()
+		}
+		list.ToIDispatch().Release()
+	}
+	return kinds, nil
+}
+
+// closeWorkbook は変更を保存せずにブックを閉じる。
+func closeWorkbook(workbook *ole.IDispatch) error {
+	if _, err := oleutil.PutProperty(workbook, "Saved", true); err != nil {
+		return err
+	}
+	_, err := oleutil.CallMethod(workbook, "Close", false)
+	return err
+}
+
+// Wordアプリケーションの作成
+func createWordApp() (*ole.IDispatch, error) {
+	if unknown, err := oleutil.CreateObject("Word.Application"); err != nil {
+		return nil, err
+	} else {
+		wordApp, err := unknown.QueryInterface(ole.IID_IDispatch)
+		if err != nil {
+			return nil, err
+		}
+		return wordApp, nil
+	}
+}
+
+// wordStartMu は Word の起動前後のプロセスを比較する間、他のワーカーの Word の起動を待たせる。
+var wordStartMu sync.Mutex
+
+// startWordApp は Word を起動し、起動前後のプロセスの一覧から、起動した Word のプロセスIDを返す。
+// 起動済みの Word に接続した場合など、プロセスを特定できない場合は 0 を返す。
+func startWordApp() (*ole.IDispatch, int, error) {
+	wordStartMu.Lock()
+	defer wordStartMu.Unlock()
+
+	before, snapErr := processIDs("WINWORD.EXE")
+	word, err := createWordApp()
+	if err != nil {
+		return nil, 0, err
+	}
+	if snapErr != nil {
+		slog.Warn("Wordのプロセスを特定できませんでした。", "err", snapErr)
+		return word, 0, nil
+	}
+	after, err := processIDs("WINWORD.EXE")
+	if err != nil {
+		slog.Warn("Wordのプロセスを特定できませんでした。", "err", err)
+		return word, 0, nil
+	}
+
+	var started []int
+	for pid := range after {
+		if !before[pid] {
+			started = append(started, pid)
+		}
+	}
+	if len(started) != 1 {
+		slog.Warn("Wordのプロセスを特定できませんでした。", "起動したプロセス数", len(started))
+		return word, 0, nil
+	}
+	return word, started[0], nil
+}
+
+// Excelアプリケーションの作成
+func createExcelApp() (*ole.IDispatch, error) {
+	if unknown, err := oleutil.CreateObject("Excel.Application"); err != nil {
+		return nil, err
+	} else {
+		excelApp, err := unknown.QueryInterface(ole.IID_IDispatch)
+		if err != nil {
+			return nil, err
+		}
+		return excelApp, nil
+	}
+}
+
+// PowerPointオブジェクトの生成
+func createPowerPointApp() (*ole.IDispatch, error) {
+	if unknown, err := oleutil.CreateObject("PowerPoint.Application"); err != nil {
+		return nil, err
+	} else {
+		ppointApp, err := unknown.QueryInterface(ole.IID_IDispatch)
+		if err != nil {
+			return nil, err
+		}
+		return ppointApp, nil
+	}
+}
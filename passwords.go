@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// loadPasswords は -passwords で指定されたファイルから、パスワードを1行に1つずつ読み込む。
+// 前後の空白もパスワードの一部として扱い、空行のみを無視する。
+// パスワードはログやレポートに出力しない。
+func loadPasswords(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var passwords []string
+	scanner := bufio.NewScanner(f)
+	for first := true; scanner.Scan(); first = false {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if first {
+			// メモ帳で保存したファイルの BOM
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if line == "" {
+			continue
+		}
+		passwords = append(passwords, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return passwords, nil
+}
+
+// tryPasswords は passwords の順に open を呼び出し、ファイルを開けた時点で終了する。
+// passwords が空の場合は、パスワード無しで1回だけ呼び出す。
+// wrongPassword はエラーがパスワードの誤りによるものかを判定する。パスワードの誤り以外のエラーは、
+// 破損したファイルや共有フォルダのアクセス拒否などを隠さないように、次のパスワードを試さずにそのまま返す。
+// どのパスワードでも開けなかった場合は ErrPassword を返す。
+func tryPasswords(passwords []string, wrongPassword func(error) bool, open func(password string) error) error {
+	if len(passwords) == 0 {
+		return open("")
+	}
+	for _, password := range passwords {
+		err := open(password)
+		if err == nil {
+			return nil
+		}
+		if !wrongPassword(err) {
+			return err
+		}
+	}
+	return ErrPassword
+}
+
+// passwordError はメッセージからパスワードを取り除いたエラー。
+// 元のエラーは errors.Is や errors.As で判定できる。
+type passwordError struct {
+	err      error
+	password string
+}
+
+// hidePassword は err のメッセージから password を取り除いたエラーを返す。
+// PowerPoint はファイル名の後に ::password:: を付けて開くため、その部分は取り除いて元のファイル名に戻し、
+// それ以外の箇所のパスワードは **** に置き換える。
+func hidePassword(err error, password string) error {
+	if err == nil || password == "" {
+		return err
+	}
+	return &passwordError{err: err, password: password}
+}
+
+func (e *passwordError) Error() string {
+	msg := strings.ReplaceAll(e.err.Error(), "::"+e.password+"::", "")
+	return strings.ReplaceAll(msg, e.password, "****")
+}
+
+func (e *passwordError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadPasswords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "passwords.txt")
+	if err := os.WriteFile(path, []byte("\ufeffsales2019\r\n\r\n pass word \r\nlast"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := loadPasswords(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"sales2019", " pass word ", "last"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loadPasswords = %q, want %q", got, want)
+	}
+}
+
+func TestTryPasswords(t *testing.T) {
+	errWrong := errors.New("wrong password")
+	open := func(correct string, tried *[]string) func(string) error {
+		return func(password string) error {
+			*tried = append(*tried, password)
+			if password != correct {
+				return errWrong
+			}
+			return nil
+		}
+	}
+
+	isWrong := func(err error) bool { return errors.Is(err, errWrong) }
+
+	var tried []string
+	if err := tryPasswords([]string{"a", "b", "c"}, isWrong, open("b", &tried)); err != nil {
+		t.Errorf("tryPasswords: %v", err)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(tried, want) {
+		t.Errorf("tried = %q, want %q", tried, want)
+	}
+
+	tried = nil
+	if err := tryPasswords([]string{"a", "b"}, isWrong, open("z", &tried)); !errors.Is(err, ErrPassword) {
+		t.Errorf("tryPasswords = %v, want ErrPassword", err)
+	}
+
+	// パスワードが無い場合は、パスワード無しで開く。
+	tried = nil
+	if err := tryPasswords(nil, isWrong, open("", &tried)); err != nil || !reflect.DeepEqual(tried, []string{""}) {
+		t.Errorf("tryPasswords(nil) = %v, tried %q", err, tried)
+	}
+
+	// パスワードの誤り以外のエラーは、次のパスワードを試さずにそのまま返す。
+	tried = nil
+	errDenied := errors.New("access denied")
+	err := tryPasswords([]string{"a", "b"}, isWrong, func(password string) error {
+		tried = append(tried, password)
+		return errDenied
+	})
+	if err != errDenied || !reflect.DeepEqual(tried, []string{"a"}) {
+		t.Errorf("tryPasswords = %v, tried %q, want %v after the first password", err, tried, errDenied)
+	}
+}
+
+func TestRunPasswords(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"open.xlsx", "locked.xlsx"} {
+		writeCFB(t, filepath.Join(dir, name), map[string][]byte{
+			"EncryptionInfo":   make([]byte, 200),
+			"EncryptedPackage": make([]byte, 5000),
+		})
+	}
+
+	backend := newFakeBackend()
+	backend.fail["locked.xlsx"] = ErrPassword
+	result, err := run([]string{dir}, backend.newConverter, runOptions{Passwords: []string{"secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Converted != 1 || result.Failed() != 1 {
+		t.Errorf("result = %+v, want 1 converted and 1 failed", result)
+	}
+	for _, r := range result.Files {
+		if filepath.Base(r.Source) == "locked.xlsx" && (r.Status != statusFailed || r.Error == "") {
+			t.Errorf("report = %+v, want failed with error", r)
+		}
+	}
+}
+
+func TestHidePassword(t *testing.T) {
+	errOpen := errors.New(`C:\share\deck.pptx::s3cret:: を開けません (s3cret)`)
+	err := hidePassword(errOpen, "s3cret")
+	if want := `C:\share\deck.pptx を開けません (****)`; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+	// 元のエラーで判定できる。
+	if !errors.Is(err, errOpen) {
+		t.Error("errors.Is(err, errOpen) = false")
+	}
+	if err := hidePassword(errOpen, ""); err != errOpen {
+		t.Errorf("hidePassword without a password = %v", err)
+	}
+}
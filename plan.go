@@ -0,0 +1,398 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"Office2PDF/internal/ooxml"
+	"golang.org/x/exp/slog"
+)
+
+// スキップする理由。
+const (
+	skipUpToDate          = "PDFが最新"
+	skipPasswordProtected = "パスワード保護"
+	skipLocked            = "使用中"
+	skipNoSheets          = "全てのシートが対象外"
+)
+
+// planEntry は1ファイル分のPDF変換の計画。
+type planEntry struct {
+	App AppType `json:"-"`
+	// Source は変換元ファイルのパス。
+	Source string `json:"source"`
+	// Type は変換に使用するアプリケーション。
+	Type string `json:"type"`
+	// Target は出力するPDFファイルのパス。シートごとに出力する場合は、シート名の部分が {sheet} となる。
+	Target string `json:"target"`
+	// Skip が true の場合は変換しない。理由は Reason。
+	Skip   bool   `json:"skip"`
+	Reason string `json:"reason,omitempty"`
+	// Error は指定された対象を確認できなかった場合のエラー。変換せず、失敗とする。
+	Error string `json:"error,omitempty"`
+	// ExcludedSheets はシート名により変換対象外となるExcelのシート。
+	// Preview はPDFに出力される内容。どちらも -dry-run と -report の場合のみ設定する。
+	ExcludedSheets []string         `json:"excludedSheets,omitempty"`
+	Preview        *documentPreview `json:"preview,omitempty"`
+	// Locked が true の場合は、他のユーザーがファイルを開いている。LockedBy はそのユーザー名 (不明な場合は空)。
+	Locked   bool   `json:"locked,omitempty"`
+	LockedBy string `json:"lockedBy,omitempty"`
+	// Export はPDF出力の指定。
+	Export exportOptions `json:"-"`
+
+	// hash は -manifest で記録する、変換元ファイルのハッシュ値。
+	hash string
+	// err は Error のエラー。
+	err error
+}
+
+// sourceFile はPDF変換対象ファイル。
+type sourceFile struct {
+	App  AppType
+	Path string
+	// Root は出力先の決定に使用するフォルダ。
+	// フォルダが指定された場合はそのフォルダ、ファイルが指定された場合はファイルのあるフォルダ。
+	Root string
+}
+
+// buildPlan は targets で指定されたフォルダとファイルから、PDF変換対象ファイルを取得し、
+// ファイルごとの出力先と、変換するかどうかを決める。
+// 実際の変換と -dry-run は、同じ計画を使用する。
+// 存在しないなど確認できなかった対象は、失敗とする計画を末尾に追加し、他の対象の変換を続ける。
+func buildPlan(targets []string, opts runOptions) ([]*planEntry, error) {
+	files, failed, err := findSourceFiles(targets, opts)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := planTargets(files, opts)
+	if err != nil {
+		return nil, err
+	}
+	checkEntries(entries, opts)
+	return append(entries, failed...), nil
+}
+
+// findSourceFiles は targets で指定されたフォルダとファイルから、PDF変換対象ファイルを取得する。
+// 同じファイルが複数回指定された場合は、1回だけ返す。
+// 確認できなかった対象は、エラーを設定した計画として failed に返す。
+func findSourceFiles(targets []string, opts runOptions) (files []sourceFile, failed []*planEntry, err error) {
+	seen := map[string]bool{}
+	add := func(f sourceFile) error {
+		full, err := filepath.Abs(f.Path)
+		if err != nil {
+			return err
+		}
+		if !seen[full] {
+			seen[full] = true
+			files = append(files, f)
+		}
+		return nil
+	}
+
+	for _, target := range targets {
+		info, err := os.Stat(target)
+		if err != nil {
+			slog.Warn("PDF変換対象を確認できませんでした。", "err", err, "path", target)
+			e := &planEntry{Source: target, Error: err.Error(), err: err}
+			if app, ok := appTypeOf(target); ok {
+				e.App, e.Type = app, app.String()
+			}
+			failed = append(failed, e)
+			continue
+		}
+
+		// ファイルが指定された場合は、フォルダ内のファイルと同じ規則で変換する。
+		if !info.IsDir() {
+			if strings.HasPrefix(filepath.Base(target), "~") {
+				slog.Warn("PDF変換対象外のファイルです。", "path", target)
+				continue
+			}
+			app, ok := detectAppType(target)
+			if !ok {
+				slog.Warn("PDF変換対象外のファイルです。", "path", target)
+				continue
+			}
+			if err := add(sourceFile{App: app, Path: target, Root: filepath.Dir(target)}); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		wopts, err := opts.walkOptions(target)
+		if err != nil {
+			return nil, nil, err
+		}
+		// 処理対象フォルダから、PDF変換対象ファイルの一覧を取得する。
+		xlsPaths, docPaths, pptPaths, err := getFilePaths(target, wopts)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, group := range []struct {
+			app   AppType
+			files []string
+		}{
+			{AppExcel, xlsPaths},
+			{AppWord, docPaths},
+			{AppPowerPoint, pptPaths},
+		} {
+			for _, path := range group.files {
+				if err := add(sourceFile{App: group.app, Path: path, Root: target}); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+	}
+	return files, failed, nil
+}
+
+// planTargets はファイルごとに、出力するPDFファイルのパスを決める。
+func planTargets(files []sourceFile, opts runOptions) ([]*planEntry, error) {
+	entries := make([]*planEntry, 0, len(files))
+	for _, f := range files {
+		// 変換元ファイルのパスから、PDFファイルのパスを取得する。
+		naming := pdfNaming{Root: f.Root, OutDir: opts.OutDir, Template: opts.NameTemplate}
+		pdfPath, _, err := getPdfPath(f.Path, naming)
+		if err != nil {
+			return nil, err
+		}
+		export := formats.exportOptionsFor(f.App, f.Path)
+		// シートごとに出力する場合は、出力先に {sheet} を残し、変換時にシート名に置き換える。
+		// シートの無いWordとPowerPointでは、{sheet} は拡張子を除いたファイル名とする。
+		if opts.SplitSheets && f.App == AppExcel {
+			export.SplitSheets = true
+			pdfPath = splitSheetsTarget(pdfPath)
+		} else {
+			pdfPath = strings.ReplaceAll(pdfPath, sheetMarker, getFileNameWithoutExt(f.Path))
+		}
+		entries = append(entries, &planEntry{
+			App:    f.App,
+			Source: f.Path,
+			Type:   f.App.String(),
+			Target: pdfPath,
+			Export: export,
+		})
+	}
+
+	// 種類の異なるファイルが同じPDFに出力されないように、出力先を決め直す。
+	if err := resolveCollisions(entries, opts.Collision); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// checkEntries はファイルの状態を確認し、変換しないファイルにスキップの理由を設定する。
+func checkEntries(entries []*planEntry, opts runOptions) {
+	if opts.Incremental {
+		for _, e := range entries {
+			ok, hash, err := opts.upToDate(e.Source, e.Target)
+			if err != nil {
+				slog.Warn(filepath.Base(e.Source)+" 変換済みかどうかを判定できませんでした。", "err", err)
+			}
+			e.Skip, e.hash = ok, hash
+			if ok {
+				e.Reason = skipUpToDate
+			}
+		}
+	}
+
+	// パスワードで保護されたファイルは、Officeがパスワードの入力を待って応答しなくなるため、
+	// -passwords が指定されていない場合は変換しない。
+	for _, e := range entries {
+		if e.Skip {
+			continue
+		}
+		encrypted, err := isEncrypted(e.Source)
+		if err != nil {
+			slog.Warn(filepath.Base(e.Source)+" パスワード保護の有無を判定できませんでした。", "err", err)
+			continue
+		}
+		if !encrypted {
+			continue
+		}
+		if len(opts.Passwords) > 0 {
+			slog.Info(filepath.Base(e.Source) + " パスワードで保護されています。登録されたパスワードで開きます。")
+			continue
+		}
+		slog.Warn(filepath.Base(e.Source) + " パスワードで保護されているためスキップします。")
+		e.Skip, e.Reason = true, skipPasswordProtected
+	}
+
+	// 他のユーザーが開いているファイルは、-locked に従って扱う。
+	for _, e := range entries {
+		if e.Skip {
+			continue
+		}
+		owner, locked := lockOwner(e.Source, e.App)
+		if !locked {
+			continue
+		}
+		e.Locked, e.LockedBy = true, owner
+		switch opts.Locked {
+		case lockedSkip:
+			slog.Warn(filepath.Base(e.Source)+" 他のユーザーが開いているためスキップします。", "ユーザー", owner)
+			e.Skip, e.Reason = true, skipLocked
+		case lockedRetry:
+			slog.Info(filepath.Base(e.Source)+" 他のユーザーが開いているため、後で変換します。", "ユーザー", owner)
+		default:
+			slog.Info(filepath.Base(e.Source)+" 他のユーザーが開いているため、コピーを変換します。", "ユーザー", owner)
+		}
+	}
+}
+
+// documentPreview はOfficeを起動せずに OOXML 形式のファイルから読み取った、PDFに出力される内容。
+type documentPreview struct {
+	Title  string `json:"title,omitempty"`
+	Author string `json:"author,omitempty"`
+	// Pages はWordの文書のページ数。最後に保存した時点の値。
+	Pages int `json:"pages,omitempty"`
+	// Slides はPDFに出力するPowerPointのスライド数。
+	Slides int `json:"slides,omitempty"`
+	// Sheets はPDFに出力するExcelのシート。
+	Sheets []exportedSheet `json:"sheets,omitempty"`
+}
+
+// previewEntries は変換する計画に、Officeを起動せずに読み取ったPDFに出力される内容を設定する。
+// Excelの場合は、sheets により変換対象外となるシートも設定する。sheets が nil の場合は、全てのシートを出力する。
+// .xls など OOXML 以外の形式は、内容を読み取れないため対象外とする。
+func previewEntries(entries []*planEntry, sheets *sheetSelector) {
+	for _, e := range entries {
+		if e.Skip || e.Error != "" {
+			continue
+		}
+		if err := previewEntry(e, sheets); err != nil {
+			slog.Debug(filepath.Base(e.Source)+" ファイルの内容を読み取れませんでした。", "err", err)
+		}
+	}
+}
+
+// previewEntry は1ファイル分の計画に、PDFに出力される内容を設定する。
+func previewEntry(e *planEntry, sheets *sheetSelector) error {
+	f, err := ooxml.Open(e.Source)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	props, err := f.Properties()
+	if err != nil {
+		return err
+	}
+	p := &documentPreview{Title: props.Title, Author: props.Author}
+
+	switch e.App {
+	case AppExcel:
+		list, err := f.Sheets()
+		if err != nil {
+			return err
+		}
+		for _, s := range list {
+			if (sheets.excludesHidden() && s.Hidden()) || !sheets.selected(s.Name) {
+				e.ExcludedSheets = append(e.ExcludedSheets, s.Name)
+				continue
+			}
+			p.Sheets = append(p.Sheets, exportedSheet{Name: s.Name, Kind: s.Kind})
+		}
+	case AppWord:
+		p.Pages = props.Pages
+	case AppPowerPoint:
+		slides, err := f.Slides()
+		if err != nil {
+			return err
+		}
+		for _, s := range slides {
+			if !s.Hidden || e.Export.PrintHiddenSlides {
+				p.Slides++
+			}
+		}
+	}
+	e.Preview = p
+	return nil
+}
+
+// printPreview は -dry-run の text 形式で、PDFに出力される内容を w に出力する。
+func printPreview(w io.Writer, p *documentPreview) error {
+	if p == nil {
+		return nil
+	}
+	var lines []string
+	if p.Title != "" {
+		lines = append(lines, "タイトル: "+p.Title)
+	}
+	if p.Author != "" {
+		lines = append(lines, "作成者: "+p.Author)
+	}
+	if p.Pages > 0 {
+		lines = append(lines, fmt.Sprintf("ページ数: %d", p.Pages))
+	}
+	if p.Slides > 0 {
+		lines = append(lines, fmt.Sprintf("スライド数: %d", p.Slides))
+	}
+	if len(p.Sheets) > 0 {
+		names := make([]string, len(p.Sheets))
+		for i, s := range p.Sheets {
+			names[i] = s.Name + " (" + s.Kind + ")"
+		}
+		lines = append(lines, "出力シート: "+strings.Join(names, ", "))
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintf(w, "\t%s\n", line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkPlanFormat は -dry-run-format の指定が正しいかを確認する。
+// 対象フォルダを全て確認した後に失敗しないように、計画を作成する前に確認する。
+func checkPlanFormat(format string) error {
+	switch format {
+	case "text", "json":
+		return nil
+	}
+	return fmt.Errorf("-dry-run-format の指定が正しくありません: %s", format)
+}
+
+// printPlan は計画を format (text または json) の形式で w に出力する。
+func printPlan(w io.Writer, entries []*planEntry, format string) error {
+	switch format {
+	case "json":
+		if entries == nil {
+			entries = []*planEntry{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	case "text":
+		for _, e := range entries {
+			status := "変換"
+			if e.Skip {
+				status = "スキップ(" + e.Reason + ")"
+			}
+			if e.Error != "" {
+				status = "失敗(" + e.Error + ")"
+			}
+			if _, err := fmt.Fprintf(w, "%s\t%s\t%s -> %s\n", status, e.Type, e.Source, e.Target); err != nil {
+				return err
+			}
+			if e.Locked {
+				if _, err := fmt.Fprintf(w, "\t使用中: %s\n", e.LockedBy); err != nil {
+					return err
+				}
+			}
+			if len(e.ExcludedSheets) > 0 {
+				if _, err := fmt.Fprintf(w, "\t対象外シート: %s\n", strings.Join(e.ExcludedSheets, ", ")); err != nil {
+					return err
+				}
+			}
+			if err := printPreview(w, e.Preview); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("出力形式の指定が正しくありません: %s", format)
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// path に files の内容の zip ファイルを作成する。
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testTime は変換元ファイルを古くするための更新日時。
+var testTime = time.Date(2020, 1, 1, 0, 0, 0, 0, time.Local)
+
+const testWorkbookXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets>
+<sheet name="Summary" sheetId="1" r:id="rId1"/>
+<sheet name="_lookup" sheetId="2" r:id="rId2"/>
+<sheet name="Detail" sheetId="3" r:id="rId3"/>
+<sheet name="Archive" sheetId="4" state="hidden" r:id="rId4"/>
+<sheet name="Macro" sheetId="5" state="veryHidden" r:id="rId5"/>
+</sheets>
+</workbook>`
+
+const testWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet2.xml"/>
+<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chartsheet" Target="chartsheets/sheet1.xml"/>
+<Relationship Id="rId4" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet3.xml"/>
+<Relationship Id="rId5" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet4.xml"/>
+</Relationships>`
+
+const testCoreXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:title>予算</dc:title><dc:creator>経理部</dc:creator>
+</cp:coreProperties>`
+
+func TestDryRunPlan(t *testing.T) {
+	dir := t.TempDir()
+	writeZip(t, filepath.Join(dir, "budget.xlsx"), map[string]string{
+		"xl/workbook.xml":            testWorkbookXML,
+		"xl/_rels/workbook.xml.rels": testWorkbookRels,
+		"docProps/core.xml":          testCoreXML,
+	})
+	writeFiles(t, dir, "report.docx", "slides.pptx", "report.pdf")
+	// report.pdf を report.docx より新しくする。
+	if err := os.Chtimes(filepath.Join(dir, "report.docx"), testTime, testTime); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := buildPlan([]string{dir}, runOptions{Incremental: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	previewEntries(entries, &sheetSelector{Prefix: "_", SkipHidden: true})
+
+	var buf bytes.Buffer
+	if err := printPlan(&buf, entries, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var got []planEntry
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []planEntry{
+		{
+			Source: filepath.Join(dir, "budget.xlsx"), Type: "Excel", Target: filepath.Join(dir, "budget.pdf"),
+			ExcludedSheets: []string{"_lookup", "Archive", "Macro"},
+			Preview: &documentPreview{
+				Title:  "予算",
+				Author: "経理部",
+				Sheets: []exportedSheet{{Name: "Summary", Kind: sheetKindWorksheet}, {Name: "Detail", Kind: sheetKindChart}},
+			},
+		},
+		{Source: filepath.Join(dir, "report.docx"), Type: "Word", Target: filepath.Join(dir, "report.pdf"), Skip: true, Reason: skipUpToDate},
+		{Source: filepath.Join(dir, "slides.pptx"), Type: "PowerPoint", Target: filepath.Join(dir, "slides.pdf")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("plan = %+v, want %+v", got, want)
+	}
+
+	// 計画は実際の変換と同じ出力先を使用する。
+	backend := newFakeBackend()
+	result, err := run([]string{dir}, backend.newConverter, runOptions{Incremental: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Converted != 2 || result.Skipped != 1 {
+		t.Errorf("result = %+v, want 2 converted and 1 skipped", result)
+	}
+	for _, e := range want {
+		if _, err := os.Stat(e.Target); err != nil {
+			t.Errorf("planned PDF %s not created: %v", e.Target, err)
+		}
+	}
+}
+
+func TestPlanSplitSheets(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "budget.xlsx", "report.docx")
+
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"", []string{"budget__{sheet}.pdf", "report.pdf"}},
+		{"{name}_{sheet}.pdf", []string{"budget_{sheet}.pdf", "report_report.pdf"}},
+		{"{sheet}_{name}.pdf", []string{"{sheet}_budget.pdf", "report_report.pdf"}},
+		{"{name}/{sheet}_{ext}.pdf", []string{filepath.Join("budget", "{sheet}_xlsx.pdf"), filepath.Join("report", "report_docx.pdf")}},
+	}
+	for _, tt := range tests {
+		tmpl, err := parseNameTemplate(tt.name, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries, err := buildPlan([]string{dir}, runOptions{NameTemplate: tmpl, SplitSheets: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, e := range entries {
+			rel, err := filepath.Rel(dir, e.Target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, rel)
+			if e.Export.SplitSheets != (e.App == AppExcel) {
+				t.Errorf("%s: Export.SplitSheets = %v", e.Source, e.Export.SplitSheets)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("-name %q: targets = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPrintPlanPreview(t *testing.T) {
+	entries := []*planEntry{{
+		Source: "budget.xlsx", Type: "Excel", Target: "budget.pdf",
+		Preview: &documentPreview{Title: "予算", Sheets: []exportedSheet{{Name: "Summary", Kind: sheetKindWorksheet}, {Name: "Trend", Kind: sheetKindChart}}},
+	}}
+	var buf bytes.Buffer
+	if err := printPlan(&buf, entries, "text"); err != nil {
+		t.Fatal(err)
+	}
+	want := "変換\tExcel\tbudget.xlsx -> budget.pdf\n\tタイトル: 予算\n\t出力シート: Summary (worksheet), Trend (chart)\n"
+	if got := buf.String(); got != want {
+		t.Errorf("printPlan = %q, want %q", got, want)
+	}
+}
+
+func TestPreviewWithoutSheetSelection(t *testing.T) {
+	// -backend libreoffice はシートを選択できないため、全てのシートを出力する計画とする。
+	dir := t.TempDir()
+	writeZip(t, filepath.Join(dir, "budget.xlsx"), map[string]string{"xl/workbook.xml": testWorkbookXML})
+	entries, err := buildPlan([]string{dir}, runOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	previewEntries(entries, nil)
+	if len(entries) != 1 || entries[0].ExcludedSheets != nil || entries[0].Preview == nil || len(entries[0].Preview.Sheets) != 5 {
+		t.Errorf("plan = %+v", entries)
+	}
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+// task はPDF変換の1ファイル分の処理。
+type task struct {
+	App AppType
+	// Path は変換元ファイルのパス。
+	Path string
+	// PdfPath は出力するPDFファイルのパス。
+	PdfPath string
+	// Export はPDF出力の指定。
+	Export exportOptions
+	// Snapshot が true の場合は、変換元ファイルのコピーを変換する。
+	Snapshot bool
+}
+
+// taskResult は1ファイル分の変換の結果。
+type taskResult struct {
+	ConvertResult
+	// Err は変換に失敗した場合のエラー。
+	Err error
+	// Duration は変換にかかった時間。
+	Duration time.Duration
+}
+
+// taskQueue は変換対象ファイルを保持する、上限の無いFIFOキュー。
+// 同じアプリケーションのワーカーが共有し、先に空いたワーカーから取り出す。
+type taskQueue struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	tasks  []task
+	closed bool
+}
+
+func newTaskQueue() *taskQueue {
+	q := &taskQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push はキューの末尾に t を追加する。閉じたキューへの追加は無視する。
+func (q *taskQueue) push(t task) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.tasks = append(q.tasks, t)
+	q.cond.Signal()
+}
+
+// pop はキューの先頭から取り出す。キューが空の場合は、追加されるか閉じられるまで待つ。
+// キューが閉じられ、空になった場合は ok に false を返す。
+func (q *taskQueue) pop() (t task, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.tasks) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if len(q.tasks) == 0 {
+		return task{}, false
+	}
+	t = q.tasks[0]
+	q.tasks = q.tasks[1:]
+	return t, true
+}
+
+// close はキューを閉じる。残っているファイルは引き続き取り出せる。
+func (q *taskQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// drain はキューに残っているファイルを全て取り出す。キューは閉じない。
+func (q *taskQueue) drain() []task {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	tasks := q.tasks
+	q.tasks = nil
+	return tasks
+}
+
+// abort はキューを閉じ、残っているファイルを破棄する。
+func (q *taskQueue) abort() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.tasks = nil
+	q.cond.Broadcast()
+}
+
+// workerPool はアプリケーションの種類ごとに複数の Converter を起動し、
+// 共有のキューからファイルを取り出してPDFに変換する。
+// ワーカーはそれぞれ専用のスレッドでアプリケーションを起動し、終了まで使用する。
+type workerPool struct {
+	newConverter converterFactory
+	opts         runOptions
+	// onDone はファイルの変換が終わるごとに、成否に関わらずワーカーから呼び出される。
+	onDone func(t task, r taskResult)
+
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	queues  map[AppType]*taskQueue
+	workers map[AppType]int
+
+	converted int
+	errs      []error
+}
+
+func newWorkerPool(newConverter converterFactory, opts runOptions) *workerPool {
+	return &workerPool{
+		newConverter: newConverter,
+		opts:         opts,
+		queues:       map[AppType]*taskQueue{},
+		workers:      map[AppType]int{},
+	}
+}
+
+// Submit は t をアプリケーションの種類ごとの変換キューに追加する。
+// ワーカーは、ファイルが追加されるごとに、同時実行数の上限まで起動する。
+func (p *workerPool) Submit(t task) {
+	app := t.App
+	p.mu.Lock()
+	q, ok := p.queues[app]
+	if !ok {
+		q = newTaskQueue()
+		p.queues[app] = q
+	}
+	if p.workers[app] < p.opts.jobs(app) {
+		p.workers[app]++
+		p.wg.Add(1)
+		go p.work(app, q)
+	}
+	// ワーカーが起動に失敗して終了する前に追加するため、ロックしたまま追加する。
+	q.push(t)
+	p.mu.Unlock()
+}
+
+// Close はキューを閉じ、全てのワーカーの終了を待って、変換できたファイルの数とエラーを返す。
+func (p *workerPool) Close() (converted int, errs []error) {
+	p.mu.Lock()
+	for _, q := range p.queues {
+		q.close()
+	}
+	p.mu.Unlock()
+
+	p.wg.Wait()
+	return p.converted, p.errs
+}
+
+// takeResults は前回の呼び出し以降に変換できたファイルの数とエラーを返し、記録を消去する。
+// 監視のように同じ workerPool を使い続ける場合に、エラーが溜まり続けないようにする。
+func (p *workerPool) takeResults() (converted int, errs []error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	converted, errs = p.converted, p.errs
+	p.converted, p.errs = 0, nil
+	return converted, errs
+}
+
+// work はアプリケーションを起動し、キューが閉じられるまでファイルをPDFに変換する。
+// 変換が制限時間を超えた場合は、アプリケーションを破棄して新しく起動し直す。
+func (p *workerPool) work(app AppType, q *taskQueue) {
+	// detached は、応答しなくなったアプリケーションを強制終了できず、
+	// このワーカーを切り離して、代わりのワーカーを起動した場合に true となる。
+	var detached atomic.Bool
+	defer func() {
+		if !detached.Load() {
+			p.wg.Done()
+		}
+	}()
+
+	// COMは初期化したスレッドで使用する必要があるため、ワーカーをスレッドに固定する。
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	conv := p.newConverter(app)
+	if err := conv.Open(); err != nil {
+		p.openFailed(app, q, err)
+		return
+	}
+	defer func() {
+		if conv == nil {
+			return
+		}
+		if err := conv.Quit(); err != nil {
+			p.addError(err)
+		}
+	}()
+
+	for {
+		t, ok := q.pop()
+		if !ok {
+			return
+		}
+		path := t.Path
+
+		start := time.Now()
+		res, err := p.convert(conv, t, func() {
+			detached.Store(true)
+			p.finish(q, t, taskResult{Err: ErrTimeout, Duration: time.Since(start)})
+			slog.Warn(filepath.Base(path) + " " + app.String() + "を強制終了できないため、新しい" + app.String() + "で残りのファイルを変換します。")
+			p.wg.Add(1)
+			go p.work(app, q)
+			p.wg.Done()
+		})
+		if detached.Load() {
+			// 結果は切り離した時点で記録済みのため、アプリケーションを破棄して終了する。
+			discard(conv)
+			conv = nil
+			return
+		}
+		p.finish(q, t, taskResult{ConvertResult: res, Err: err, Duration: time.Since(start)})
+
+		if errors.Is(err, ErrTimeout) {
+			slog.Warn(filepath.Base(path)+" 変換がタイムアウトしたため、"+app.String()+"を再起動します。", "制限時間", p.opts.Timeout)
+			discard(conv)
+			conv = p.newConverter(app)
+			if err := conv.Open(); err != nil {
+				conv = nil
+				p.openFailed(app, q, err)
+				return
+			}
+		}
+	}
+}
+
+// openFailed はアプリケーションを起動できずに終了するワーカーの枠を空ける。
+// 他に動いているワーカーが無い場合は、キューに残っているファイルを err で失敗とする。
+// 次に Submit されたときに、新しいワーカーで起動し直す。
+func (p *workerPool) openFailed(app AppType, q *taskQueue, err error) {
+	p.mu.Lock()
+	p.workers[app]--
+	var tasks []task
+	if p.workers[app] == 0 {
+		tasks = q.drain()
+	}
+	p.mu.Unlock()
+
+	if len(tasks) == 0 {
+		p.addError(err)
+		return
+	}
+	for _, t := range tasks {
+		p.finish(q, t, taskResult{Err: err})
+	}
+}
+
+// finish は1ファイル分の変換の結果を記録する。
+func (p *workerPool) finish(q *taskQueue, t task, r taskResult) {
+	if p.onDone != nil {
+		p.onDone(t, r)
+	}
+	if r.Err != nil {
+		p.addError(&FileError{Path: t.Path, Err: r.Err})
+		if p.opts.FailFast {
+			q.abort()
+		}
+		return
+	}
+	if r.SkipReason != "" {
+		return
+	}
+	p.mu.Lock()
+	p.converted++
+	p.mu.Unlock()
+}
+
+// convert は t のファイルを conv でPDFに変換する。
+// COMは初期化したスレッドで使用する必要があるため、変換は呼び出し元のワーカーのスレッドで行い、
+// 制限時間の監視だけを別のゴルーチンで行う。制限時間を超えた場合は、conv が Killer であれば強制終了して
+// 変換を終わらせ、それ以外は ctx の終了により変換を中断させて、ErrTimeout を返す。
+// 強制終了できない場合は stuck を呼び出し、変換が終わるまで待つ。
+func (p *workerPool) convert(conv Converter, t task, stuck func()) (ConvertResult, error) {
+	if p.opts.Timeout <= 0 {
+		return convertFile(context.Background(), conv, t)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.opts.Timeout)
+	defer cancel()
+
+	finished := make(chan struct{})
+	watched := make(chan struct{})
+	var timedOut bool
+	go func() {
+		defer close(watched)
+		select {
+		case <-finished:
+			return
+		case <-ctx.Done():
+		}
+		timedOut = true
+		k, ok := conv.(Killer)
+		if !ok {
+			return
+		}
+		if err := k.Kill(); err != nil {
+			slog.Warn("アプリケーションを強制終了できませんでした。", "err", err)
+			stuck()
+		}
+	}()
+
+	res, err := convertFile(ctx, conv, t)
+	close(finished)
+	<-watched
+	if timedOut {
+		return ConvertResult{}, ErrTimeout
+	}
+	return res, err
+}
+
+// discard は応答しなくなった、または強制終了した conv のアプリケーションを破棄する。
+// Open と同じスレッドで呼び出す。
+func discard(conv Converter) {
+	if k, ok := conv.(Killer); ok {
+		k.Release()
+		return
+	}
+	if err := conv.Quit(); err != nil {
+		slog.Warn("アプリケーションを終了できませんでした。", "err", err)
+	}
+}
+
+func (p *workerPool) addError(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.errs = append(p.errs, err)
+}
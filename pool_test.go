@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunJobs(t *testing.T) {
+	dir := t.TempDir()
+	var want []string
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("book%02d.xlsx", i)
+		writeFiles(t, dir, name)
+		want = append(want, "convert "+name)
+	}
+	writeFiles(t, dir, "doc.docx")
+
+	backend := newFakeBackend()
+	opts := runOptions{Jobs: map[AppType]int{AppExcel: 3}}
+	result, err := run([]string{dir}, backend.newConverter, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Converted != 11 || len(result.Errs) != 0 {
+		t.Fatalf("result = %+v, want 11 converted", result)
+	}
+
+	count := map[string]int{}
+	var converted []string
+	for _, event := range backend.eventsOf(AppExcel) {
+		switch event {
+		case "open", "quit":
+			count[event]++
+		default:
+			converted = append(converted, event)
+		}
+	}
+	// Excelは3つ起動し、それぞれ終了する。
+	if count["open"] != 3 || count["quit"] != 3 {
+		t.Errorf("open = %d, quit = %d, want 3", count["open"], count["quit"])
+	}
+	// 各ファイルは1回だけ変換する。
+	sort.Strings(converted)
+	if fmt.Sprint(converted) != fmt.Sprint(want) {
+		t.Errorf("converted = %v, want %v", converted, want)
+	}
+	// 指定の無いWordは1つだけ起動する。
+	if got := backend.eventsOf(AppWord); len(got) != 3 {
+		t.Errorf("Word events = %v", got)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.docx", "b.docx", "c.docx")
+
+	backend := newFakeBackend()
+	backend.hang["b.docx"] = true
+
+	result, err := run([]string{dir}, backend.newConverter, runOptions{Timeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Converted != 2 || len(result.Errs) != 1 || !errors.Is(result.Errs[0], ErrTimeout) {
+		t.Fatalf("result = %+v, want b.docx to time out", result)
+	}
+
+	// タイムアウトしたWordは強制終了し、新しいWordで残りのファイルを変換する。
+	want := []string{"open", "convert a.docx", "convert b.docx", "kill", "open", "convert c.docx", "quit"}
+	if got := backend.eventsOf(AppWord); !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestRunTimeoutKillFailed(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.docx", "b.docx", "c.docx")
+
+	backend := newFakeBackend()
+	backend.hang["b.docx"] = true
+	backend.killErr = errors.New("access denied")
+
+	result, err := run([]string{dir}, backend.newConverter, runOptions{Timeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Converted != 2 || len(result.Errs) != 1 || !errors.Is(result.Errs[0], ErrTimeout) {
+		t.Fatalf("result = %+v, want b.docx to time out", result)
+	}
+
+	// 強制終了できない場合も、新しいWordで残りのファイルを変換する。
+	want := []string{"open", "convert a.docx", "convert b.docx", "kill", "open", "convert c.docx", "quit"}
+	if got := backend.eventsOf(AppWord); !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestPoolTakeResults(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.docx", "b.docx", "c.docx")
+
+	backend := newFakeBackend()
+	backend.fail["b.docx"] = errors.New("broken")
+	pool := newWorkerPool(backend.newConverter, runOptions{})
+	var done sync.WaitGroup
+	pool.onDone = func(task, taskResult) { done.Done() }
+	submit := func(names ...string) {
+		for _, name := range names {
+			done.Add(1)
+			pool.Submit(task{App: AppWord, Path: filepath.Join(dir, name), PdfPath: filepath.Join(dir, name+".pdf")})
+		}
+		done.Wait()
+	}
+
+	submit("a.docx", "b.docx")
+	if converted, errs := pool.takeResults(); converted != 1 || len(errs) != 1 {
+		t.Errorf("takeResults = %d, %v, want 1 converted and 1 error", converted, errs)
+	}
+
+	// 集計済みの結果は、次の集計と Close に含めない。
+	submit("c.docx")
+	if converted, errs := pool.takeResults(); converted != 1 || len(errs) != 0 {
+		t.Errorf("takeResults = %d, %v, want 1 converted", converted, errs)
+	}
+	if converted, errs := pool.Close(); converted != 0 || len(errs) != 0 {
+		t.Errorf("Close = %d, %v, want no results", converted, errs)
+	}
+}
+
+func TestPoolOpenFailed(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.docx", "b.docx")
+
+	backend := newFakeBackend()
+	errOpen := errors.New("起動できません")
+	backend.openErrs = []error{errOpen}
+	pool := newWorkerPool(backend.newConverter, runOptions{})
+	var done sync.WaitGroup
+	pool.onDone = func(task, taskResult) { done.Done() }
+	submit := func(name string) {
+		done.Add(1)
+		pool.Submit(task{App: AppWord, Path: filepath.Join(dir, name), PdfPath: filepath.Join(dir, name+".pdf")})
+		done.Wait()
+	}
+
+	// 起動に失敗した場合は、キューのファイルをそのエラーで失敗とする。
+	submit("a.docx")
+	converted, errs := pool.takeResults()
+	var fe *FileError
+	if converted != 0 || len(errs) != 1 || !errors.As(errs[0], &fe) || fe.Path != filepath.Join(dir, "a.docx") || !errors.Is(errs[0], errOpen) {
+		t.Errorf("takeResults = %d, %v, want a.docx to fail with the open error", converted, errs)
+	}
+
+	// 次のファイルは、新しいワーカーで起動し直して変換する。
+	submit("b.docx")
+	if converted, errs := pool.takeResults(); converted != 1 || len(errs) != 0 {
+		t.Errorf("takeResults = %d, %v, want 1 converted", converted, errs)
+	}
+	pool.Close()
+	want := []string{"open", "open", "convert b.docx", "quit"}
+	if got := backend.eventsOf(AppWord); !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package main
+
+import "errors"
+
+// hwnd のウィンドウを作成したプロセスのIDを返す。Windows以外では取得できない。
+func processIDFromWindow(hwnd uintptr) (int, error) {
+	return 0, errors.New("ウィンドウのプロセスIDは Windows でのみ取得できます。")
+}
+
+// processIDs は実行ファイル名が exe のプロセスのIDを返す。Windows以外では取得できない。
+func processIDs(exe string) (map[int]bool, error) {
+	return nil, errors.New("プロセスの一覧は Windows でのみ取得できます。")
+}
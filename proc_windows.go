@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	user32                       = syscall.NewLazyDLL("user32.dll")
+	procGetWindowThreadProcessId = user32.NewProc("GetWindowThreadProcessId")
+
+	kernel32                     = syscall.NewLazyDLL("kernel32.dll")
+	procCreateToolhelp32Snapshot = kernel32.NewProc("CreateToolhelp32Snapshot")
+	procProcess32FirstW          = kernel32.NewProc("Process32FirstW")
+	procProcess32NextW           = kernel32.NewProc("Process32NextW")
+)
+
+// hwnd のウィンドウを作成したプロセスのIDを返す。
+func processIDFromWindow(hwnd uintptr) (int, error) {
+	var pid uint32
+	r, _, err := procGetWindowThreadProcessId.Call(hwnd, uintptr(unsafe.Pointer(&pid)))
+	if r == 0 {
+		return 0, err
+	}
+	return int(pid), nil
+}
+
+const th32csSnapProcess = 0x00000002
+
+// processEntry32 は Win32 の PROCESSENTRY32W 構造体。
+type processEntry32 struct {
+	Size            uint32
+	Usage           uint32
+	ProcessID       uint32
+	DefaultHeapID   uintptr
+	ModuleID        uint32
+	Threads         uint32
+	ParentProcessID uint32
+	PriClassBase    int32
+	Flags           uint32
+	ExeFile         [syscall.MAX_PATH]uint16
+}
+
+// processIDs は実行ファイル名が exe (大文字と小文字を区別しない) のプロセスのIDを返す。
+func processIDs(exe string) (map[int]bool, error) {
+	r, _, err := procCreateToolhelp32Snapshot.Call(th32csSnapProcess, 0)
+	snapshot := syscall.Handle(r)
+	if snapshot == syscall.InvalidHandle {
+		return nil, err
+	}
+	defer syscall.CloseHandle(snapshot)
+
+	pids := map[int]bool{}
+	entry := processEntry32{}
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	r, _, err = procProcess32FirstW.Call(uintptr(snapshot), uintptr(unsafe.Pointer(&entry)))
+	if r == 0 {
+		return nil, err
+	}
+	for r != 0 {
+		if strings.EqualFold(syscall.UTF16ToString(entry.ExeFile[:]), exe) {
+			pids[int(entry.ProcessID)] = true
+		}
+		r, _, _ = procProcess32NextW.Call(uintptr(snapshot), uintptr(unsafe.Pointer(&entry)))
+	}
+	return pids, nil
+}
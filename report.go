@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// レポートに出力する、ファイルごとの変換結果。
+const (
+	statusConverted = "converted"
+	statusSkipped   = "skipped"
+	statusFailed    = "failed"
+)
+
+// fileReport はレポートに出力する1ファイル分の結果。
+type fileReport struct {
+	Source string `json:"source"`
+	Type   string `json:"type"`
+	Output string `json:"output"`
+	// Outputs はシートごとに出力した場合の、シートごとのPDFファイル。Output はシート名の部分が {sheet} となる。
+	Outputs []string `json:"outputs,omitempty"`
+	Status  string   `json:"status"`
+	// Reason はスキップした理由。
+	Reason string `json:"reason,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// Duration は変換にかかった秒数。
+	Duration float64 `json:"duration"`
+	// Count はPDFに出力したシート数またはスライド数。
+	Count int `json:"count"`
+	// Sheets はPDFに出力したExcelのシートと、その種類。
+	Sheets []exportedSheet `json:"sheets,omitempty"`
+	// Preview はOfficeを起動せずに読み取った、PDFに出力される内容。
+	Preview *documentPreview `json:"preview,omitempty"`
+	// Size は出力したPDFファイルのバイト数。シートごとに出力した場合は合計。
+	Size int64 `json:"size"`
+	// Locked が true の場合は、他のユーザーがファイルを開いていた。LockedBy はそのユーザー名。
+	Locked   bool   `json:"locked"`
+	LockedBy string `json:"lockedBy,omitempty"`
+}
+
+// newFileReport は計画から、まだ変換していない状態のレポートを作成する。
+func newFileReport(e *planEntry) *fileReport {
+	r := &fileReport{Source: e.Source, Type: e.Type, Output: e.Target, Locked: e.Locked, LockedBy: e.LockedBy, Preview: e.Preview}
+	if e.Skip {
+		r.Status = statusSkipped
+		r.Reason = e.Reason
+	}
+	if e.Error != "" {
+		r.Status = statusFailed
+		r.Error = e.Error
+	}
+	return r
+}
+
+// setResult は変換の結果をレポートに設定する。
+func (r *fileReport) setResult(res taskResult) {
+	r.Duration = res.Duration.Seconds()
+	r.Count = res.Count
+	r.Sheets = res.Sheets
+	if res.Err != nil {
+		r.Status = statusFailed
+		r.Error = res.Err.Error()
+		return
+	}
+	if res.SkipReason != "" {
+		r.Status = statusSkipped
+		r.Reason = res.SkipReason
+		return
+	}
+	r.Status = statusConverted
+	r.Error = ""
+	if len(res.Outputs) > 0 {
+		r.Outputs, r.Size = res.Outputs, 0
+		for _, path := range res.Outputs {
+			if info, err := os.Stat(path); err == nil {
+				r.Size += info.Size()
+			}
+		}
+		return
+	}
+	if info, err := os.Stat(r.Output); err == nil {
+		r.Size = info.Size()
+	}
+}
+
+// setSkipped は変換を始めた後に、変換しないことにしたファイルをスキップとする。
+// lockedBy はファイルを開いていたユーザー名。
+func (r *fileReport) setSkipped(reason, lockedBy string) {
+	r.Status = statusSkipped
+	r.Reason = reason
+	r.LockedBy = lockedBy
+}
+
+// setNotConverted は、アプリケーションを起動できなかったなどの理由で、
+// 変換を試みなかったファイルを失敗とする。
+func (r *fileReport) setNotConverted() {
+	if r.Status == "" {
+		r.Status = statusFailed
+		r.Error = "変換されませんでした。"
+	}
+}
+
+// checkReportPath はレポートの出力形式を、ファイルの拡張子 (.json または .csv) から判定できるかを確認する。
+func checkReportPath(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".csv":
+		return nil
+	}
+	return fmt.Errorf("レポートの拡張子は .json または .csv を指定してください: %s", path)
+}
+
+// writeReport は files を path に出力する。形式はファイルの拡張子で決める。
+func writeReport(path string, files []*fileReport) error {
+	if err := checkReportPath(path); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		if files == nil {
+			files = []*fileReport{}
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(files); err != nil {
+			return err
+		}
+		return f.Close()
+	}
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"source", "type", "output", "status", "reason", "error", "duration", "count", "size", "locked", "lockedBy", "outputs", "sheets", "title", "author", "pages", "slides"})
+	for _, r := range files {
+		w.Write(append([]string{
+			r.Source,
+			r.Type,
+			r.Output,
+			r.Status,
+			r.Reason,
+			r.Error,
+			strconv.FormatFloat(r.Duration, 'f', 3, 64),
+			strconv.Itoa(r.Count),
+			strconv.FormatInt(r.Size, 10),
+			strconv.FormatBool(r.Locked),
+			r.LockedBy,
+			strings.Join(r.Outputs, ";"),
+			formatSheets(r.Sheets),
+		}, r.Preview.csvFields()...))
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// formatSheets はCSVのレポートに出力するシートの一覧を、シート名:種類 の ; 区切りで返す。
+// Excelのシート名には : を使用できないため、シート名と種類を区別できる。
+func formatSheets(sheets []exportedSheet) string {
+	list := make([]string, len(sheets))
+	for i, s := range sheets {
+		list[i] = s.Name + ":" + s.Kind
+	}
+	return strings.Join(list, ";")
+}
+
+// csvFields はCSVのレポートに出力する title、author、pages、slides の値を返す。
+func (p *documentPreview) csvFields() []string {
+	if p == nil {
+		return []string{"", "", "", ""}
+	}
+	return []string{p.Title, p.Author, strconv.Itoa(p.Pages), strconv.Itoa(p.Slides)}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRunReport(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.xlsx", "b.xlsx", "c.docx", "c.pdf")
+	if err := os.Chtimes(filepath.Join(dir, "c.docx"), testTime, testTime); err != nil {
+		t.Fatal(err)
+	}
+
+	backend := newFakeBackend()
+	backend.fail["b.xlsx"] = errors.New("broken")
+	result, err := run([]string{dir}, backend.newConverter, runOptions{Incremental: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]fileReport{
+		"a.xlsx": {Status: statusConverted, Count: 1, Size: int64(len(fakePdf))},
+		"b.xlsx": {Status: statusFailed, Error: "broken"},
+		"c.docx": {Status: statusSkipped, Reason: skipUpToDate},
+	}
+	if len(result.Files) != len(want) {
+		t.Fatalf("len(Files) = %d, want %d", len(result.Files), len(want))
+	}
+	for _, r := range result.Files {
+		w := want[filepath.Base(r.Source)]
+		if r.Status != w.Status || r.Reason != w.Reason || r.Error != w.Error || r.Count != w.Count || r.Size != w.Size {
+			t.Errorf("report of %s = %+v, want %+v", filepath.Base(r.Source), r, w)
+		}
+	}
+
+	jsonPath := filepath.Join(t.TempDir(), "report.json")
+	if err := writeReport(jsonPath, result.Files); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []fileReport
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[0].Source != result.Files[0].Source || got[0].Output != result.Files[0].Output {
+		t.Errorf("JSON report = %+v", got)
+	}
+
+	csvPath := filepath.Join(t.TempDir(), "report.csv")
+	if err := writeReport(csvPath, result.Files); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 || records[0][3] != "status" || records[2][3] != statusFailed {
+		t.Errorf("CSV report = %v", records)
+	}
+
+	if err := writeReport(filepath.Join(t.TempDir(), "report.txt"), result.Files); err == nil {
+		t.Error("writeReport with .txt succeeded, want error")
+	}
+}
+
+func TestReportSheets(t *testing.T) {
+	r := &fileReport{Source: "budget.xlsx", Type: "Excel", Output: "budget.pdf"}
+	r.setResult(taskResult{ConvertResult: ConvertResult{
+		Count: 2,
+		Sheets: []exportedSheet{
+			{Name: "Summary", Kind: sheetKindWorksheet},
+			{Name: "Trend", Kind: sheetKindChart},
+		},
+	}})
+
+	jsonPath := filepath.Join(t.TempDir(), "report.json")
+	if err := writeReport(jsonPath, []*fileReport{r}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []fileReport
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || !reflect.DeepEqual(got[0].Sheets, r.Sheets) {
+		t.Errorf("JSON report = %+v", got)
+	}
+
+	csvPath := filepath.Join(t.TempDir(), "report.csv")
+	if err := writeReport(csvPath, []*fileReport{r}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	col := -1
+	for i, name := range records[0] {
+		if name == "sheets" {
+			col = i
+		}
+	}
+	if col < 0 || records[1][col] != "Summary:worksheet;Trend:chart" {
+		t.Errorf("CSV report = %v", records)
+	}
+}
+
+func TestRunReportPreview(t *testing.T) {
+	dir := t.TempDir()
+	writeZip(t, filepath.Join(dir, "budget.xlsx"), map[string]string{
+		"xl/workbook.xml":   testWorkbookXML,
+		"docProps/core.xml": testCoreXML,
+	})
+
+	backend := newFakeBackend()
+	result, err := run([]string{dir}, backend.newConverter, runOptions{Preview: true, Sheets: &sheetSelector{Prefix: "_", SkipHidden: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Files) != 1 {
+		t.Fatalf("len(Files) = %d, want 1", len(result.Files))
+	}
+	p := result.Files[0].Preview
+	want := &documentPreview{Title: "予算", Author: "経理部", Sheets: []exportedSheet{{Name: "Summary", Kind: sheetKindWorksheet}, {Name: "Detail", Kind: sheetKindWorksheet}}}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("Preview = %+v, want %+v", p, want)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"Office2PDF/internal/ooxml"
+)
+
+// Excelのシートの種類。Officeで変換した場合と、計画のプレビューで同じ名前にする。
+const (
+	sheetKindWorksheet = ooxml.KindWorksheet
+	sheetKindChart     = ooxml.KindChart
+	sheetKindDialog    = ooxml.KindDialog
+	sheetKindMacro     = ooxml.KindMacro
+)
+
+// exportedSheet はPDFに出力したExcelのシート。
+type exportedSheet struct {
+	Name string `json:"name"`
+	// Kind はシートの種類。sheetKindWorksheet、sheetKindChart、sheetKindDialog、sheetKindMacro のいずれか。
+	Kind string `json:"kind"`
+}
+
+// sheetSelector はPDFに出力するExcelのシートを、シート名で選択する。
+// 変換対象外の条件のいずれかに一致するシートは出力しない。
+// Include が指定されている場合は、いずれかに一致するシートのみを出力する。
+type sheetSelector struct {
+	// Prefix は変換対象外とするシート名の先頭文字 (-g)。空の場合は使用しない。
+	Prefix string
+	// Include と Exclude は、変換対象と変換対象外とするシート名の正規表現。
+	Include []*regexp.Regexp
+	Exclude []*regexp.Regexp
+	// Names は変換対象外とするシート名 (完全一致)。
+	Names map[string]bool
+	// SkipHidden が true の場合、非表示 (Visible が xlSheetHidden または xlSheetVeryHidden) のシートを出力しない。
+	SkipHidden bool
+}
+
+// newSheetSelector は -g、-sheet-include、-sheet-exclude、-sheet-exclude-name の指定から sheetSelector を作成する。
+func newSheetSelector(prefix string, include, exclude, names []string) (*sheetSelector, error) {
+	s := &sheetSelector{Prefix: prefix, Names: map[string]bool{}}
+	for _, expr := range include {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		s.Include = append(s.Include, re)
+	}
+	for _, expr := range exclude {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		s.Exclude = append(s.Exclude, re)
+	}
+	for _, name := range names {
+		s.Names[name] = true
+	}
+	return s, nil
+}
+
+// empty はシートを選択する条件が無い場合に true を返す。
+func (s *sheetSelector) empty() bool {
+	return s == nil || (s.Prefix == "" && len(s.Include) == 0 && len(s.Exclude) == 0 && len(s.Names) == 0 && !s.SkipHidden)
+}
+
+// excludesHidden は非表示のシートを出力しない場合に true を返す。
+func (s *sheetSelector) excludesHidden() bool {
+	return s != nil && s.SkipHidden
+}
+
+// selected は name のシートをPDFに出力する場合に true を返す。
+func (s *sheetSelector) selected(name string) bool {
+	if s.empty() {
+		return true
+	}
+	if s.Prefix != "" && strings.HasPrefix(name, s.Prefix) {
+		return false
+	}
+	if s.Names[name] {
+		return false
+	}
+	for _, re := range s.Exclude {
+		if re.MatchString(name) {
+			return false
+		}
+	}
+	if len(s.Include) == 0 {
+		return true
+	}
+	for _, re := range s.Include {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
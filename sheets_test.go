@@ -0,0 +1,66 @@
+package main
+
+import "testing"
+
+func TestSheetSelector(t *testing.T) {
+	tests := []struct {
+		name    string
+		prefix  string
+		include []string
+		exclude []string
+		names   []string
+		want    map[string]bool
+	}{
+		{
+			name: "empty",
+			want: map[string]bool{"Summary": true, "_lookup": true},
+		},
+		{
+			name:   "prefix",
+			prefix: "_",
+			want:   map[string]bool{"Summary": true, "_lookup": false},
+		},
+		{
+			name:    "include",
+			include: []string{`^Q[1-4]$`, `(?i)^summary`},
+			want:    map[string]bool{"Q1": true, "q1": false, "SUMMARY 2023": true, "Detail": false},
+		},
+		{
+			name:    "exclude",
+			prefix:  "_",
+			exclude: []string{`(?i)draft`, `^tmp`},
+			want:    map[string]bool{"Summary": true, "Draft 2": false, "tmp1": false, "_lookup": false},
+		},
+		{
+			name:    "names",
+			include: []string{`^Q`},
+			exclude: []string{`Q4`},
+			names:   []string{"Q2", "Notes"},
+			want:    map[string]bool{"Q1": true, "Q2": false, "Q2 ": true, "Q3": true, "Q4": false, "Notes": false},
+		},
+	}
+	for _, tt := range tests {
+		s, err := newSheetSelector(tt.prefix, tt.include, tt.exclude, tt.names)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		for sheet, want := range tt.want {
+			if got := s.selected(sheet); got != want {
+				t.Errorf("%s: selected(%q) = %v, want %v", tt.name, sheet, got, want)
+			}
+		}
+	}
+
+	// 非表示のシートのみを出力しない場合も、シートを選択する条件とする。
+	if s := (&sheetSelector{SkipHidden: true}); s.empty() || !s.excludesHidden() {
+		t.Error("SkipHidden: want non-empty selector that excludes hidden sheets")
+	}
+	var none *sheetSelector
+	if !none.empty() || none.excludesHidden() || !none.selected("Sheet1") {
+		t.Error("nil selector: want empty selector that selects all sheets")
+	}
+
+	if _, err := newSheetSelector("", []string{"("}, nil, nil); err == nil {
+		t.Error("newSheetSelector with invalid regexp: want error")
+	}
+}
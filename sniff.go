@@ -0,0 +1,136 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/exp/slog"
+)
+
+// zipSignature は ZIP ファイル (.xlsx, .docx, .pptx などの OOXML 形式) の先頭4バイト。
+var zipSignature = []byte{'P', 'K', 0x03, 0x04}
+
+// detectAppType は path のPDF変換に使用するアプリケーションを判定する。
+// 拡張子がPDF変換対象のファイルについて、ファイルの内容から形式を判定し、
+// 拡張子と内容が一致しない場合は、警告を出力して内容を優先する。
+// 内容から判定できない場合は、拡張子で判定する。
+func detectAppType(path string) (AppType, bool) {
+	byExt, ok := appTypeOf(path)
+	if !ok {
+		return 0, false
+	}
+	app, ok, err := sniffAppType(path)
+	if err != nil {
+		slog.Warn(filepath.Base(path)+" ファイルの内容から形式を判定できませんでした。拡張子で判定します。", "err", err)
+		return byExt, true
+	}
+	if !ok {
+		return byExt, true
+	}
+	if app != byExt {
+		slog.Warn(filepath.Base(path)+" 拡張子とファイルの内容が一致しません。内容に合わせて変換します。", "拡張子", filepath.Ext(path), "内容", app.String())
+	}
+	return app, true
+}
+
+// sniffAppType は path の内容から、PDF変換に使用するアプリケーションを判定する。
+// OOXML 形式は [Content_Types].xml、旧形式は OLE 複合ファイルのストリーム名で判定する。
+// 空のファイルなど、内容から判定できない場合は ok に false を返す。
+func sniffAppType(path string) (app AppType, ok bool, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, false, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, false, err
+	}
+	header := make([]byte, len(cfbSignature))
+	if _, err := io.ReadFull(f, header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+
+	switch {
+	case bytes.HasPrefix(header, zipSignature):
+		zr, err := zip.NewReader(f, info.Size())
+		if err != nil {
+			return 0, false, err
+		}
+		return sniffOOXML(zr)
+	case bytes.Equal(header, cfbSignature):
+		cf, err := openCFB(f, info.Size())
+		if err != nil {
+			return 0, false, err
+		}
+		app, ok := sniffCFB(cf)
+		return app, ok, nil
+	}
+	return 0, false, nil
+}
+
+// sniffOOXML は [Content_Types].xml に記述された、メインパートのコンテンツタイプで判定する。
+func sniffOOXML(zr *zip.Reader) (AppType, bool, error) {
+	f, err := zr.Open("[Content_Types].xml")
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	defer f.Close()
+
+	var types struct {
+		Overrides []struct {
+			ContentType string `xml:",attr"`
+		} `xml:"Override"`
+	}
+	if err := xml.NewDecoder(f).Decode(&types); err != nil {
+		return 0, false, err
+	}
+	for _, o := range types.Overrides {
+		ct := strings.ToLower(o.ContentType)
+		// 例: application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml
+		//     application/vnd.ms-excel.sheet.binary.macroenabled.main
+		if !strings.HasSuffix(ct, ".main+xml") && !strings.HasSuffix(ct, ".main") {
+			continue
+		}
+		switch {
+		case strings.Contains(ct, ".spreadsheetml."), strings.Contains(ct, "/vnd.ms-excel."):
+			return AppExcel, true, nil
+		case strings.Contains(ct, ".wordprocessingml."), strings.Contains(ct, "/vnd.ms-word."):
+			return AppWord, true, nil
+		case strings.Contains(ct, ".presentationml."), strings.Contains(ct, "/vnd.ms-powerpoint."):
+			return AppPowerPoint, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// sniffCFB は OLE 複合ファイルに含まれるストリームの名前で判定する。
+func sniffCFB(cf *cfbFile) (AppType, bool) {
+	for _, s := range []struct {
+		name string
+		app  AppType
+	}{
+		{"Workbook", AppExcel},
+		{"Book", AppExcel},
+		{"WordDocument", AppWord},
+		{"PowerPoint Document", AppPowerPoint},
+	} {
+		if _, ok := cf.stream(s.name); ok {
+			return s.app, true
+		}
+	}
+	return 0, false
+}
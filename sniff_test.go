@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"unicode/utf16"
+)
+
+// writeCFB は streams をストリームとして含む OLE 複合ファイル (バージョン3) を作成する。
+// 4096バイト未満のストリームはミニストリームに、それ以外は通常のセクタに格納する。
+func writeCFB(t *testing.T, path string, streams map[string][]byte) {
+	t.Helper()
+	const sectorSize = 512
+	names := make([]string, 0, len(streams))
+	for name := range streams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// セクタ0はFATに使用する。
+	sectors := [][]byte{nil}
+	fat := []uint32{0xFFFFFFFD}
+	alloc := func(data []byte) uint32 {
+		start := uint32(len(sectors))
+		for off := 0; off < len(data); off += sectorSize {
+			sec := make([]byte, sectorSize)
+			copy(sec, data[off:])
+			sectors = append(sectors, sec)
+			fat = append(fat, uint32(len(sectors)))
+		}
+		fat[len(fat)-1] = cfbEndOfChain
+		return start
+	}
+
+	dir := make([]byte, (len(names)+1+3)/4*sectorSize)
+	writeEntry := func(i int, name string, typ byte, start uint32, size int) {
+		e := dir[i*128 : (i+1)*128]
+		u := utf16.Encode([]rune(name))
+		for j, c := range u {
+			binary.LittleEndian.PutUint16(e[j*2:], c)
+		}
+		binary.LittleEndian.PutUint16(e[0x40:], uint16(len(u)*2+2))
+		e[0x42] = typ
+		binary.LittleEndian.PutUint32(e[0x44:], cfbFreeSect)
+		binary.LittleEndian.PutUint32(e[0x48:], cfbFreeSect)
+		binary.LittleEndian.PutUint32(e[0x4C:], cfbFreeSect)
+		binary.LittleEndian.PutUint32(e[0x74:], start)
+		binary.LittleEndian.PutUint64(e[0x78:], uint64(size))
+	}
+
+	var miniStream []byte
+	var miniFat []uint32
+	for i, name := range names {
+		content := streams[name]
+		start := uint32(cfbEndOfChain)
+		switch {
+		case len(content) == 0:
+		case len(content) < 4096:
+			start = uint32(len(miniFat))
+			for off := 0; off < len(content); off += cfbMiniSectorSize {
+				sec := make([]byte, cfbMiniSectorSize)
+				copy(sec, content[off:])
+				miniStream = append(miniStream, sec...)
+				miniFat = append(miniFat, uint32(len(miniFat)+1))
+			}
+			miniFat[len(miniFat)-1] = cfbEndOfChain
+		default:
+			start = alloc(content)
+		}
+		writeEntry(i+1, name, cfbTypeStream, start, len(content))
+		if i+1 < len(names) {
+			binary.LittleEndian.PutUint32(dir[(i+1)*128+0x48:], uint32(i+2))
+		}
+	}
+
+	rootStart, miniFatStart := uint32(cfbEndOfChain), uint32(cfbEndOfChain)
+	numMiniFat := 0
+	if len(miniStream) > 0 {
+		rootStart = alloc(miniStream)
+		buf := make([]byte, (len(miniFat)*4+sectorSize-1)/sectorSize*sectorSize)
+		for i := range buf {
+			buf[i] = 0xFF
+		}
+		for i, v := range miniFat {
+			binary.LittleEndian.PutUint32(buf[i*4:], v)
+		}
+		miniFatStart = alloc(buf)
+		numMiniFat = len(buf) / sectorSize
+	}
+	writeEntry(0, "Root Entry", cfbTypeRoot, rootStart, len(miniStream))
+	binary.LittleEndian.PutUint32(dir[0x4C:], 1)
+	dirStart := alloc(dir)
+	if len(fat) > sectorSize/4 {
+		t.Fatal("writeCFB: too many sectors")
+	}
+
+	header := make([]byte, sectorSize)
+	copy(header, cfbSignature)
+	binary.LittleEndian.PutUint16(header[0x18:], 0x3E)
+	binary.LittleEndian.PutUint16(header[0x1A:], 3)
+	binary.LittleEndian.PutUint16(header[0x1C:], 0xFFFE)
+	binary.LittleEndian.PutUint16(header[0x1E:], 9)
+	binary.LittleEndian.PutUint16(header[0x20:], 6)
+	binary.LittleEndian.PutUint32(header[0x2C:], 1)
+	binary.LittleEndian.PutUint32(header[0x30:], dirStart)
+	binary.LittleEndian.PutUint32(header[0x38:], 4096)
+	binary.LittleEndian.PutUint32(header[0x3C:], miniFatStart)
+	binary.LittleEndian.PutUint32(header[0x40:], uint32(numMiniFat))
+	binary.LittleEndian.PutUint32(header[0x44:], cfbEndOfChain)
+	for i := 0; i < 109; i++ {
+		binary.LittleEndian.PutUint32(header[0x4C+i*4:], cfbFreeSect)
+	}
+	binary.LittleEndian.PutUint32(header[0x4C:], 0)
+
+	sectors[0] = make([]byte, sectorSize)
+	for i := range sectors[0] {
+		sectors[0][i] = 0xFF
+	}
+	for i, v := range fat {
+		binary.LittleEndian.PutUint32(sectors[0][i*4:], v)
+	}
+
+	buf := header
+	for _, sec := range sectors {
+		buf = append(buf, sec...)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// contentTypesXML はメインパートのコンテンツタイプが contentType の [Content_Types].xml。
+func contentTypesXML(contentType string) string {
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>
+<Override PartName="/main.xml" ContentType="` + contentType + `"/>
+</Types>`
+}
+
+func TestSniffAppType(t *testing.T) {
+	dir := t.TempDir()
+	writeZip(t, filepath.Join(dir, "book.zip"), map[string]string{
+		"[Content_Types].xml": contentTypesXML("application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"),
+	})
+	writeZip(t, filepath.Join(dir, "macro.zip"), map[string]string{
+		"[Content_Types].xml": contentTypesXML("application/vnd.ms-word.document.macroEnabled.main+xml"),
+	})
+	writeZip(t, filepath.Join(dir, "slides.zip"), map[string]string{
+		"[Content_Types].xml": contentTypesXML("application/vnd.openxmlformats-officedocument.presentationml.presentation.main+xml"),
+	})
+	writeZip(t, filepath.Join(dir, "plain.zip"), map[string]string{"readme.txt": "hello"})
+	writeCFB(t, filepath.Join(dir, "book.ole"), map[string][]byte{"Workbook": nil, "\x05SummaryInformation": nil})
+	writeCFB(t, filepath.Join(dir, "doc.ole"), map[string][]byte{"WordDocument": nil, "1Table": nil})
+	writeCFB(t, filepath.Join(dir, "slides.ole"), map[string][]byte{"PowerPoint Document": nil, "Current User": nil})
+	writeFiles(t, dir, "empty")
+
+	tests := []struct {
+		name string
+		app  AppType
+		ok   bool
+	}{
+		{"book.zip", AppExcel, true},
+		{"macro.zip", AppWord, true},
+		{"slides.zip", AppPowerPoint, true},
+		{"plain.zip", 0, false},
+		{"book.ole", AppExcel, true},
+		{"doc.ole", AppWord, true},
+		{"slides.ole", AppPowerPoint, true},
+		{"empty", 0, false},
+	}
+	for _, tt := range tests {
+		app, ok, err := sniffAppType(filepath.Join(dir, tt.name))
+		if err != nil {
+			t.Errorf("sniffAppType(%q): %v", tt.name, err)
+			continue
+		}
+		if app != tt.app || ok != tt.ok {
+			t.Errorf("sniffAppType(%q) = %v, %v, want %v, %v", tt.name, app, ok, tt.app, tt.ok)
+		}
+	}
+}
+
+func TestGetFilePathsSniff(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "upper.XLSX", "legacy.doc", "memo.txt")
+	// 拡張子と内容が異なるファイルは、内容に合わせて変換する。
+	writeZip(t, filepath.Join(dir, "renamed.xlsx"), map[string]string{
+		"[Content_Types].xml": contentTypesXML("application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"),
+	})
+	writeCFB(t, filepath.Join(dir, "renamed.ppt"), map[string][]byte{"Workbook": nil})
+
+	xls, doc, ppt, err := getFilePaths(dir, walkOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := func(paths []string) []string {
+		var names []string
+		for _, p := range paths {
+			names = append(names, filepath.Base(p))
+		}
+		return names
+	}
+	if got, want := base(xls), []string{"renamed.ppt", "upper.XLSX"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("excel = %v, want %v", got, want)
+	}
+	if got, want := base(doc), []string{"legacy.doc", "renamed.xlsx"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("word = %v, want %v", got, want)
+	}
+	if got := base(ppt); len(got) != 0 {
+		t.Errorf("powerpoint = %v, want none", got)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadFileList(t *testing.T) {
+	got, err := readFileList(strings.NewReader("a.xlsx\r\n\n  b/c.docx  \nd\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a.xlsx", "b/c.docx", "d"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readFileList = %v, want %v", got, want)
+	}
+}
+
+func TestBuildPlanTargets(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "folder/a.xlsx", "folder/b.docx", "single/c.pptx", "single/d.xls", "single/memo.txt", "single/~$e.docx")
+
+	targets := []string{
+		filepath.Join(dir, "folder"),
+		filepath.Join(dir, "single", "c.pptx"),
+		filepath.Join(dir, "single", "memo.txt"),
+		filepath.Join(dir, "single", "~$e.docx"),
+		// 重複して指定されたファイルは1回だけ変換する。
+		filepath.Join(dir, "folder", "a.xlsx"),
+		filepath.Join(dir, "single", "d.xls"),
+	}
+	entries, err := buildPlan(targets, runOptions{OutDir: filepath.Join(dir, "out")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, e := range entries {
+		rel, err := filepath.Rel(dir, e.Target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, e.Type+" "+filepath.Base(e.Source)+" -> "+filepath.ToSlash(rel))
+	}
+	want := []string{
+		"Excel a.xlsx -> out/a.pdf",
+		"Word b.docx -> out/b.pdf",
+		"PowerPoint c.pptx -> out/c.pdf",
+		"Excel d.xls -> out/d.pdf",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("plan = %v, want %v", got, want)
+	}
+
+	// 存在しない対象は、エラーを設定した計画とする。
+	entries, err = buildPlan([]string{filepath.Join(dir, "missing.xlsx")}, runOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Error == "" || entries[0].App != AppExcel {
+		t.Errorf("plan with missing target = %+v, want an error", entries)
+	}
+}
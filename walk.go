@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/exp/slog"
+)
+
+// シンボリックリンクの扱い (-symlinks)。
+// Windows のジャンクションも、シンボリックリンクとして扱う。
+const (
+	symlinksIgnore = "ignore"
+	symlinksFollow = "follow"
+)
+
+// checkSymlinkPolicy は -symlinks の指定が正しいかを確認する。
+func checkSymlinkPolicy(policy string) error {
+	switch policy {
+	case "", symlinksIgnore, symlinksFollow:
+		return nil
+	}
+	return fmt.Errorf("-symlinks の指定が正しくありません: %s", policy)
+}
+
+// walkOptions は変換対象ファイルを探す時の、フォルダの辿り方の指定。
+type walkOptions struct {
+	// Filter は変換対象外とするファイルとフォルダ。nil の場合は全てを対象とする。
+	Filter *pathFilter
+	// MaxDepth は辿るフォルダの深さの上限。1 の場合は対象フォルダ直下のファイルのみ。0 の場合は無制限。
+	MaxDepth int
+	// SkipHidden が true の場合、隠しフォルダ (. で始まるフォルダ、Windows の隠し属性・システム属性のフォルダ) を辿らない。
+	SkipHidden bool
+	// Symlinks はシンボリックリンクの扱い。symlinksFollow の場合はリンク先を辿り、それ以外は無視する。
+	Symlinks string
+}
+
+// walker は walkOptions に従ってフォルダを辿る。
+type walker struct {
+	opts walkOptions
+	fn   func(path string, info fs.FileInfo)
+	// visited は辿ったフォルダの実体のパス。リンクによるループと、同じフォルダの重複を防ぐ。
+	visited map[string]bool
+}
+
+// walkFiles は root 配下のファイルごとに fn を呼び出す。ファイルはフォルダごとに名前順に辿る。
+// 読み込めないサブフォルダは、警告を出力してスキップする。root を読み込めない場合はエラーを返す。
+func walkFiles(root string, opts walkOptions, fn func(path string, info fs.FileInfo)) error {
+	w := &walker{opts: opts, fn: fn, visited: map[string]bool{}}
+	return w.walkDir(root, 1)
+}
+
+// walkDir は深さ depth のフォルダ dir を辿る。
+func (w *walker) walkDir(dir string, depth int) error {
+	real, err := filepath.EvalSymlinks(dir)
+	if err == nil {
+		real, err = filepath.Abs(real)
+	}
+	if err != nil {
+		return w.skipDir(dir, depth, err)
+	}
+	if w.visited[real] {
+		slog.Warn("辿り済みのフォルダのためスキップします。", "path", dir, "実体", real)
+		return nil
+	}
+	w.visited[real] = true
+
+	if w.opts.Filter != nil {
+		if err := w.opts.Filter.loadIgnoreFile(dir); err != nil {
+			slog.Warn(ignoreFileName+" を読み込めませんでした。", "err", err, "path", dir)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return w.skipDir(dir, depth, err)
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		info, err := entry.Info()
+		if err != nil {
+			slog.Warn("ファイルの情報を取得できないためスキップします。", "err", err, "path", path)
+			continue
+		}
+
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if w.opts.Symlinks != symlinksFollow {
+				slog.Debug("シンボリックリンクのためスキップします。", "path", path)
+				continue
+			}
+			if info, err = os.Stat(path); err != nil {
+				slog.Warn("リンク先が存在しないためスキップします。", "err", err, "path", path)
+				continue
+			}
+		}
+
+		if info.IsDir() {
+			if w.opts.SkipHidden && isHiddenDir(path, info) {
+				slog.Debug("隠しフォルダのためスキップします。", "path", path)
+				continue
+			}
+			if w.opts.Filter != nil && w.opts.Filter.excluded(path, true) {
+				continue
+			}
+			if w.opts.MaxDepth > 0 && depth >= w.opts.MaxDepth {
+				continue
+			}
+			if err := w.walkDir(path, depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if w.opts.Filter != nil && w.opts.Filter.excluded(path, false) {
+			continue
+		}
+		w.fn(path, info)
+	}
+	return nil
+}
+
+// skipDir は読み込めないフォルダを警告してスキップする。対象フォルダ自体の場合はエラーを返す。
+func (w *walker) skipDir(dir string, depth int, err error) error {
+	if depth == 1 {
+		return err
+	}
+	slog.Warn("フォルダを読み込めないためスキップします。", "err", err, "path", dir)
+	return nil
+}
+
+// isHiddenDir は . で始まるフォルダ、または隠し属性・システム属性のフォルダの場合に true を返す。
+func isHiddenDir(path string, info fs.FileInfo) bool {
+	if strings.HasPrefix(info.Name(), ".") {
+		return true
+	}
+	return hasHiddenAttribute(info)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+// walkFiles で見つかったファイルの、dir からの相対パスを返す。
+func walkedFiles(t *testing.T, dir string, opts walkOptions) []string {
+	t.Helper()
+	var got []string
+	err := walkFiles(dir, opts, func(path string, info os.FileInfo) {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, filepath.ToSlash(rel))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestWalkFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, ".git/x.docx", "a.xlsx", "sub/b.xlsx", "sub/deep/c.xlsx")
+
+	tests := []struct {
+		opts walkOptions
+		want []string
+	}{
+		{walkOptions{}, []string{".git/x.docx", "a.xlsx", "sub/b.xlsx", "sub/deep/c.xlsx"}},
+		{walkOptions{SkipHidden: true}, []string{"a.xlsx", "sub/b.xlsx", "sub/deep/c.xlsx"}},
+		{walkOptions{SkipHidden: true, MaxDepth: 1}, []string{"a.xlsx"}},
+		{walkOptions{SkipHidden: true, MaxDepth: 2}, []string{"a.xlsx", "sub/b.xlsx"}},
+	}
+	for _, tt := range tests {
+		if got := walkedFiles(t, dir, tt.opts); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v: got %v, want %v", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestWalkFilesSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("シンボリックリンクの作成に管理者権限が必要なため、Windows では実行しない")
+	}
+	dir := t.TempDir()
+	other := t.TempDir()
+	writeFiles(t, dir, "a.xlsx", "sub/b.xlsx")
+	writeFiles(t, other, "linked.docx")
+	// リンク先のフォルダと、上位のフォルダへのループ
+	if err := os.Symlink(other, filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(dir, filepath.Join(dir, "sub", "loop")); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := walkedFiles(t, dir, walkOptions{Symlinks: symlinksIgnore}), []string{"a.xlsx", "sub/b.xlsx"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ignore: got %v, want %v", got, want)
+	}
+	if got, want := walkedFiles(t, dir, walkOptions{Symlinks: symlinksFollow}), []string{"a.xlsx", "link/linked.docx", "sub/b.xlsx"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("follow: got %v, want %v", got, want)
+	}
+}
+
+func TestWalkFilesUnreadableDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("アクセス権の変更が chmod で行えないため、Windows では実行しない")
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.xlsx", "locked/b.xlsx", "z.docx")
+	locked := filepath.Join(dir, "locked")
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(locked, 0o755)
+	if _, err := os.ReadDir(locked); err == nil {
+		t.Skip("アクセス権に関わらずフォルダを読み込めるため、実行しない")
+	}
+
+	if got, want := walkedFiles(t, dir, walkOptions{}), []string{"a.xlsx", "z.docx"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
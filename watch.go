@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+// watchOptions は watch の指定。
+type watchOptions struct {
+	// Interval はフォルダを確認する間隔。
+	Interval time.Duration
+	// Debounce はファイルの変更が落ち着いたと判断するまでの時間。
+	// 保存が続いている間は変換せず、最後の変更から Debounce が経過した後に変換する。
+	Debounce time.Duration
+}
+
+// fileState はファイルの変更を検出するための、更新日時とサイズ。
+type fileState struct {
+	ModTime time.Time
+	Size    int64
+}
+
+// watcher は対象フォルダを定期的に確認し、追加・変更されたファイルを検出する。
+// ネットワークドライブでも動作するように、ファイルシステムの通知ではなくポーリングで検出する。
+type watcher struct {
+	targets  []string
+	opts     runOptions
+	debounce time.Duration
+
+	// files は前回確認した時のファイル。nil の場合はまだ確認していない。
+	files map[string]watchedFile
+	// pending は変更を検出したファイルと、最後に変更を検出した時刻。
+	pending map[string]time.Time
+}
+
+// watchedFile は監視しているファイル。
+type watchedFile struct {
+	sourceFile
+	state fileState
+}
+
+func newWatcher(targets []string, opts runOptions, debounce time.Duration) *watcher {
+	return &watcher{targets: targets, opts: opts, debounce: debounce, pending: map[string]time.Time{}}
+}
+
+// poll は対象フォルダを確認し、変更が落ち着いたファイルの計画を返す。
+// 最初の呼び出しでは、その時点のファイルを変換済みとして記録する。
+func (w *watcher) poll(now time.Time) ([]*planEntry, error) {
+	files, err := w.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	if w.files != nil {
+		for path, f := range files {
+			if prev, ok := w.files[path]; !ok || prev.state != f.state {
+				w.pending[path] = now
+			}
+		}
+	}
+	w.files = files
+
+	ready := map[string]bool{}
+	for path, changed := range w.pending {
+		if _, ok := files[path]; !ok {
+			delete(w.pending, path)
+			continue
+		}
+		if now.Sub(changed) >= w.debounce {
+			ready[path] = true
+			delete(w.pending, path)
+		}
+	}
+	if len(ready) == 0 {
+		return nil, nil
+	}
+
+	// 出力先の重複を正しく判定するため、変更されていないファイルも含めて出力先を決める。
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	sources := make([]sourceFile, 0, len(paths))
+	for _, path := range paths {
+		f := files[path].sourceFile
+		if ready[path] {
+			app, ok := detectAppType(path)
+			if !ok {
+				continue
+			}
+			f.App = app
+		}
+		sources = append(sources, f)
+	}
+	all, err := planTargets(sources, w.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*planEntry
+	for _, e := range all {
+		if ready[e.Source] {
+			entries = append(entries, e)
+		}
+	}
+	checkEntries(entries, w.opts)
+	return entries, nil
+}
+
+// retry は e のファイルを、変更を検出したファイルとして再度確認する。
+func (w *watcher) retry(e *planEntry, now time.Time) {
+	w.pending[e.Source] = now
+}
+
+// scan は対象フォルダのPDF変換対象ファイルを取得する。
+// 頻繁に呼び出すため、ファイルの種類は拡張子のみで判定する。
+func (w *watcher) scan() (map[string]watchedFile, error) {
+	files := map[string]watchedFile{}
+	add := func(path, root string, info fs.FileInfo) {
+		// ~$ で始まる一時ファイルは対象外
+		if strings.HasPrefix(info.Name(), "~") {
+			return
+		}
+		app, ok := appTypeOf(path)
+		if !ok {
+			return
+		}
+		files[path] = watchedFile{
+			sourceFile: sourceFile{App: app, Path: path, Root: root},
+			state:      fileState{ModTime: info.ModTime(), Size: info.Size()},
+		}
+	}
+
+	for _, target := range w.targets {
+		info, err := os.Stat(target)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			add(target, filepath.Dir(target), info)
+			continue
+		}
+		wopts, err := w.opts.walkOptions(target)
+		if err != nil {
+			return nil, err
+		}
+		err = walkFiles(target, wopts, func(path string, info fs.FileInfo) {
+			add(path, target, info)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// watch は ctx が終了するまで targets を監視し、追加・変更されたファイルをPDFに変換する。
+// アプリケーションは監視の間起動したままにし、変換のたびに起動し直さない。
+func watch(ctx context.Context, targets []string, newConverter converterFactory, opts runOptions, wopts watchOptions) error {
+	if opts.FailFast {
+		slog.Warn("watch では -fail-fast を使用できません。")
+		opts.FailFast = false
+	}
+
+	w := newWatcher(targets, opts, wopts.Debounce)
+	if _, err := w.poll(time.Now()); err != nil {
+		return err
+	}
+
+	var mu sync.Mutex
+	hashes := map[string]string{}
+	pool := newWorkerPool(newConverter, opts)
+	pool.onDone = func(t task, r taskResult) {
+		mu.Lock()
+		hash := hashes[t.Path]
+		delete(hashes, t.Path)
+		mu.Unlock()
+		if r.Err != nil || r.SkipReason != "" || opts.Manifest == nil {
+			return
+		}
+		if err := opts.Manifest.record(t.Path, hash); err != nil {
+			slog.Warn("マニフェストに記録できませんでした。", "err", err, "path", t.Path)
+		}
+	}
+
+	// converted と failed は監視を始めてからの件数。ワーカーの結果は確認のたびに集計し、溜めない。
+	var converted, failed int
+	collect := func(n int, errs []error) {
+		converted += n
+		failed += len(errs)
+		for _, err := range errs {
+			var fe *FileError
+			if errors.As(err, &fe) {
+				slog.Error(filepath.Base(fe.Path)+" PDF変換に失敗しました。", "err", fe.Err, "path", fe.Path)
+				continue
+			}
+			slog.Error("PDF変換でエラーが発生しました。", "err", err)
+		}
+	}
+
+	slog.Info("フォルダの監視を開始しました。終了するには Ctrl+C を押してください。", "path", strings.Join(targets, ", "))
+	ticker := time.NewTicker(wopts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("フォルダの監視を終了します。")
+			collect(pool.Close())
+			slog.Info("PDF変換が終了しました。", "成功", converted, "失敗", failed)
+			if opts.Manifest != nil {
+				return opts.Manifest.save()
+			}
+			return nil
+		case now := <-ticker.C:
+			collect(pool.takeResults())
+			entries, err := w.poll(now)
+			if err != nil {
+				slog.Warn("フォルダを確認できませんでした。", "err", err)
+				continue
+			}
+			for _, e := range entries {
+				if e.Skip {
+					slog.Info(filepath.Base(e.Source)+" スキップ", "理由", e.Reason)
+					continue
+				}
+				// 他のユーザーが開いている場合は、閉じられるまで確認を続ける。
+				if e.Locked && opts.Locked == lockedRetry {
+					w.retry(e, now)
+					continue
+				}
+				slog.Info(filepath.Base(e.Source) + " 変更を検出しました。")
+				mu.Lock()
+				hashes[e.Source] = e.hash
+				mu.Unlock()
+				pool.Submit(task{App: e.App, Path: e.Source, PdfPath: e.Target, Export: e.Export, Snapshot: e.Locked})
+			}
+		}
+	}
+}
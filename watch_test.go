@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatcherPoll(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.xlsx", "old.docx")
+
+	w := newWatcher([]string{dir}, runOptions{}, 3*time.Second)
+	t0 := time.Now()
+	sources := func(now time.Time) []string {
+		t.Helper()
+		entries, err := w.poll(now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, e := range entries {
+			names = append(names, filepath.Base(e.Source))
+		}
+		return names
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 最初の確認時に存在するファイルは変換しない。
+	if got := sources(t0); got != nil {
+		t.Errorf("first poll = %v, want none", got)
+	}
+
+	write("a.xlsx", "changed")
+	write("b.docx", "new")
+	write("~$b.docx", "owner")
+	if got := sources(t0.Add(1 * time.Second)); got != nil {
+		t.Errorf("poll before debounce = %v, want none", got)
+	}
+	// 保存が続いている間は変換しない。
+	write("b.docx", "saved again")
+	if got := sources(t0.Add(2 * time.Second)); got != nil {
+		t.Errorf("poll before debounce = %v, want none", got)
+	}
+	if got := sources(t0.Add(4 * time.Second)); !reflect.DeepEqual(got, []string{"a.xlsx"}) {
+		t.Errorf("poll = %v, want [a.xlsx]", got)
+	}
+	if got := sources(t0.Add(5 * time.Second)); !reflect.DeepEqual(got, []string{"b.docx"}) {
+		t.Errorf("poll = %v, want [b.docx]", got)
+	}
+	if got := sources(t0.Add(10 * time.Second)); got != nil {
+		t.Errorf("poll without changes = %v, want none", got)
+	}
+
+	// 変換前に削除されたファイルは変換しない。
+	write("c.pptx", "new")
+	sources(t0.Add(11 * time.Second))
+	if err := os.Remove(filepath.Join(dir, "c.pptx")); err != nil {
+		t.Fatal(err)
+	}
+	if got := sources(t0.Add(20 * time.Second)); got != nil {
+		t.Errorf("poll after removal = %v, want none", got)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "existing.xlsx")
+
+	backend := newFakeBackend()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- watch(ctx, []string{dir}, backend.newConverter, runOptions{}, watchOptions{
+			Interval: 5 * time.Millisecond,
+			Debounce: 20 * time.Millisecond,
+		})
+	}()
+
+	waitPdf := func(name string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("%s was not created", name)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	writeFiles(t, dir, "first.xlsx")
+	waitPdf("first.pdf")
+	writeFiles(t, dir, "second.xlsx")
+	waitPdf("second.pdf")
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// アプリケーションは起動したまま、続けて変換する。
+	want := []string{"open", "convert first.xlsx", "convert second.xlsx", "quit"}
+	if got := backend.eventsOf(AppExcel); !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "existing.pdf")); !os.IsNotExist(err) {
+		t.Errorf("existing file was converted: %v", err)
+	}
+}